@@ -1,12 +1,17 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/middleware"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
 	"github.com/dika-bosnjak/social-media-app/pkg/routes"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,10 +19,40 @@ import (
 func init() {
 	initializers.LoadEnvVariables()
 	initializers.ConnectToDB()
+	initializers.LoadContentFilter()
 	//models.SyncDatabase()
+
+	//fill the search index with the users and the posts
+	if err := models.BuildSearchIndex(initializers.DB, initializers.SearchIndex); err != nil {
+		fmt.Println("Failed to build the search index:", err)
+	}
 }
 
 func main() {
+	//write the posts to the timelines of the friends in the background, the authors with more friends are read on the fan-out
+	workers.Timelines = workers.NewTimelineFanout(initializers.DB, 1024, 5000)
+
+	//repair the timelines and exit: go run . rebuild-timelines [user ids]
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-timelines" {
+		if err := workers.Timelines.Rebuild(os.Args[2:]...); err != nil {
+			fmt.Println("Failed to rebuild the timelines:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Timelines are rebuilt.")
+		return
+	}
+	//move the old likes into the reactions and exit: go run . migrate-likes
+	if len(os.Args) > 1 && os.Args[1] == "migrate-likes" {
+		migrated, err := models.MigrateLikes(initializers.DB)
+		if err != nil {
+			fmt.Println("Failed to migrate the likes:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Likes are migrated:", migrated)
+		return
+	}
+	go workers.Timelines.Run()
+
 	// Logging to a file.
 	f, _ := os.Create("gin.log")
 	gin.DefaultWriter = io.MultiWriter(f, os.Stdout)
@@ -31,6 +66,17 @@ func main() {
 	//import routes
 	routes.Router(r)
 
+	//publish the scheduled posts in the background, the online friends get the new posts through the websocket server
+	workers.PublishedPosts = websocketrooms.Hub
+	go workers.RunPostScheduler(initializers.DB, time.Minute)
+
+	//remove the expired stories in the background
+	go workers.RunStorySweeper(initializers.DB, 10*time.Minute)
+
+	//fetch the previews of the links in the background
+	workers.LinkPreviews = workers.NewLinkPreviewer(initializers.DB, initializers.NewLinkPreviewFetcher(), 256, 10*time.Second, 24*time.Hour)
+	go workers.LinkPreviews.Run(4)
+
 	//run the server on port 8080
 	r.Run(":8080")
 }
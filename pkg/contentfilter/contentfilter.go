@@ -0,0 +1,257 @@
+package contentfilter
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verdict is the outcome of the content check
+type Verdict string
+
+const (
+	Allow  Verdict = "allow"
+	Hold   Verdict = "hold"
+	Reject Verdict = "reject"
+)
+
+// kinds of the content that is checked
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+	KindMessage = "message"
+)
+
+type Result struct {
+	Verdict Verdict `json:"verdict"`
+	Reason  string  `json:"reason"`
+}
+
+// ContentFilter is applied on every text that users write
+type ContentFilter interface {
+	Check(userID string, kind string, text string) Result
+}
+
+// Rules configure the built-in RuleFilter, they are loaded from a JSON file
+type Rules struct {
+	RejectWords    []string `json:"reject_words"`
+	HoldWords      []string `json:"hold_words"`
+	RejectPatterns []string `json:"reject_patterns"`
+	HoldPatterns   []string `json:"hold_patterns"`
+	// more links than MaxLinks hold the content for review, 0 disables the check
+	MaxLinks int `json:"max_links"`
+	// links to the blocked domains (and their subdomains) reject the content
+	BlockedDomains []string `json:"blocked_domains"`
+	// the same text sent more than RepeatLimit times in RepeatWindowSeconds is rejected, 0 disables the check
+	RepeatLimit         int `json:"repeat_limit"`
+	RepeatWindowSeconds int `json:"repeat_window_seconds"`
+}
+
+type compiledRules struct {
+	rejectWords    *regexp.Regexp
+	holdWords      *regexp.Regexp
+	rejectPatterns []*regexp.Regexp
+	holdPatterns   []*regexp.Regexp
+	maxLinks       int
+	blockedDomains []string
+	repeatLimit    int
+	repeatWindow   time.Duration
+}
+
+var linkRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// RuleFilter is the built-in ContentFilter, its rules can be replaced while the server is running
+type RuleFilter struct {
+	mu    sync.RWMutex
+	rules compiledRules
+
+	recentMu  sync.Mutex
+	recent    map[string][]sentText
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+type sentText struct {
+	hash string
+	at   time.Time
+}
+
+func NewRuleFilter(rules Rules) (*RuleFilter, error) {
+	filter := &RuleFilter{recent: make(map[string][]sentText), now: time.Now}
+	if err := filter.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// SetRules compiles the rules and swaps them in, the old rules are kept on error
+func (filter *RuleFilter) SetRules(rules Rules) error {
+	compiled := compiledRules{
+		maxLinks:     rules.MaxLinks,
+		repeatLimit:  rules.RepeatLimit,
+		repeatWindow: time.Duration(rules.RepeatWindowSeconds) * time.Second,
+	}
+
+	var err error
+	if compiled.rejectWords, err = compileWords(rules.RejectWords); err != nil {
+		return err
+	}
+	if compiled.holdWords, err = compileWords(rules.HoldWords); err != nil {
+		return err
+	}
+	if compiled.rejectPatterns, err = compilePatterns(rules.RejectPatterns); err != nil {
+		return err
+	}
+	if compiled.holdPatterns, err = compilePatterns(rules.HoldPatterns); err != nil {
+		return err
+	}
+	for _, domain := range rules.BlockedDomains {
+		compiled.blockedDomains = append(compiled.blockedDomains, strings.ToLower(strings.TrimSpace(domain)))
+	}
+
+	filter.mu.Lock()
+	filter.rules = compiled
+	filter.mu.Unlock()
+	return nil
+}
+
+func compileWords(words []string) (*regexp.Regexp, error) {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil, nil
+	}
+	return regexp.Compile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Check runs the rejecting rules first, and then the rules that hold the content for review
+func (filter *RuleFilter) Check(userID string, kind string, text string) Result {
+	filter.mu.RLock()
+	rules := filter.rules
+	filter.mu.RUnlock()
+
+	if rules.rejectWords != nil && rules.rejectWords.MatchString(text) {
+		return Result{Verdict: Reject, Reason: "banned word"}
+	}
+	for _, re := range rules.rejectPatterns {
+		if re.MatchString(text) {
+			return Result{Verdict: Reject, Reason: "banned pattern"}
+		}
+	}
+
+	links := linkRegexp.FindAllString(text, -1)
+	for _, link := range links {
+		if isBlockedDomain(link, rules.blockedDomains) {
+			return Result{Verdict: Reject, Reason: "blocked link"}
+		}
+	}
+
+	if filter.isRepeated(rules, userID, kind, text) {
+		return Result{Verdict: Reject, Reason: "repeated message"}
+	}
+
+	if rules.holdWords != nil && rules.holdWords.MatchString(text) {
+		return Result{Verdict: Hold, Reason: "flagged word"}
+	}
+	for _, re := range rules.holdPatterns {
+		if re.MatchString(text) {
+			return Result{Verdict: Hold, Reason: "flagged pattern"}
+		}
+	}
+	if rules.maxLinks > 0 && len(links) > rules.maxLinks {
+		return Result{Verdict: Hold, Reason: "too many links"}
+	}
+
+	return Result{Verdict: Allow}
+}
+
+func isBlockedDomain(link string, blockedDomains []string) bool {
+	if len(blockedDomains) == 0 {
+		return false
+	}
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range blockedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRepeated records the text and reports whether the user sent it too many times in the window
+func (filter *RuleFilter) isRepeated(rules compiledRules, userID string, kind string, text string) bool {
+	if rules.repeatLimit <= 0 || rules.repeatWindow <= 0 {
+		return false
+	}
+
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if normalized == "" {
+		return false
+	}
+	sum := sha1.Sum([]byte(normalized))
+	hash := hex.EncodeToString(sum[:])
+	key := userID + "/" + kind
+	now := filter.now()
+
+	filter.recentMu.Lock()
+	defer filter.recentMu.Unlock()
+
+	//drop the texts that are out of the window
+	var kept []sentText
+	count := 0
+	for _, sent := range filter.recent[key] {
+		if now.Sub(sent.at) < rules.repeatWindow {
+			kept = append(kept, sent)
+			if sent.hash == hash {
+				count++
+			}
+		}
+	}
+	filter.recent[key] = append(kept, sentText{hash: hash, at: now})
+
+	//once per window forget the users that did not write anything in the window
+	if now.Sub(filter.lastSweep) >= rules.repeatWindow {
+		filter.sweepRecent(now, rules.repeatWindow)
+	}
+
+	return count >= rules.repeatLimit
+}
+
+// sweepRecent removes the keys whose texts are all out of the window, the caller holds recentMu
+func (filter *RuleFilter) sweepRecent(now time.Time, window time.Duration) {
+	for key, texts := range filter.recent {
+		if now.Sub(texts[len(texts)-1].at) >= window {
+			delete(filter.recent, key)
+		}
+	}
+	filter.lastSweep = now
+}
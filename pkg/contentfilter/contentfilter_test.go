@@ -0,0 +1,105 @@
+package contentfilter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRuleFilterCheck(t *testing.T) {
+	rules := Rules{
+		RejectWords:    []string{"scam"},
+		HoldWords:      []string{"crypto"},
+		RejectPatterns: []string{`(?i)buy\s+followers`},
+		HoldPatterns:   []string{`\d{3}-\d{3}-\d{4}`},
+		MaxLinks:       2,
+		BlockedDomains: []string{"spam.example"},
+	}
+	filter, err := NewRuleFilter(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want Verdict
+	}{
+		{name: "Plain text is allowed", text: "Nice photo!", want: Allow},
+		{name: "Banned word is rejected", text: "This is a SCAM", want: Reject},
+		{name: "Banned word inside another word is allowed", text: "scampi for dinner", want: Allow},
+		{name: "Banned pattern is rejected", text: "Buy   followers here", want: Reject},
+		{name: "Blocked domain is rejected", text: "see https://www.spam.example/offer", want: Reject},
+		{name: "Flagged word is held", text: "crypto giveaway", want: Hold},
+		{name: "Flagged pattern is held", text: "call me 555-123-4567", want: Hold},
+		{name: "Too many links are held", text: "http://a.com http://b.com www.c.com", want: Hold},
+		{name: "Links under the limit are allowed", text: "http://a.com and http://b.com", want: Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Check("user", KindPost, tt.text); got.Verdict != tt.want {
+				t.Errorf("Check(%q) = %v, want %v", tt.text, got.Verdict, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleFilterRepeatedMessages(t *testing.T) {
+	filter, err := NewRuleFilter(Rules{RepeatLimit: 2, RepeatWindowSeconds: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	filter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if got := filter.Check("user", KindMessage, "Hello  there"); got.Verdict != Allow {
+			t.Fatalf("Check() #%d = %v, want allow", i+1, got.Verdict)
+		}
+	}
+	if got := filter.Check("user", KindMessage, "hello there"); got.Verdict != Reject {
+		t.Errorf("Check() third repeat = %v, want reject", got.Verdict)
+	}
+	if got := filter.Check("other-user", KindMessage, "hello there"); got.Verdict != Allow {
+		t.Errorf("Check() other user = %v, want allow", got.Verdict)
+	}
+
+	//after the window the same text is allowed again
+	now = now.Add(2 * time.Minute)
+	if got := filter.Check("user", KindMessage, "hello there"); got.Verdict != Allow {
+		t.Errorf("Check() after the window = %v, want allow", got.Verdict)
+	}
+
+	//the users that did not write anything in the window are forgotten
+	if _, found := filter.recent["other-user/"+KindMessage]; found {
+		t.Errorf("recent texts of the inactive user are kept")
+	}
+}
+
+func TestReloadIfChanged(t *testing.T) {
+	filter, err := NewRuleFilter(Rules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`{"reject_words": ["spam"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lastModified := reloadIfChanged(filter, path, time.Time{})
+	if got := filter.Check("user", KindPost, "spam"); got.Verdict != Reject {
+		t.Errorf("Check() after reload = %v, want reject", got.Verdict)
+	}
+
+	//invalid rules keep the old ones
+	if err := os.WriteFile(path, []byte(`{"reject_patterns": ["("]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, lastModified.Add(time.Second), lastModified.Add(time.Second))
+	reloadIfChanged(filter, path, lastModified)
+	if got := filter.Check("user", KindPost, "spam"); got.Verdict != Reject {
+		t.Errorf("Check() after invalid reload = %v, want reject", got.Verdict)
+	}
+}
@@ -0,0 +1,53 @@
+package contentfilter
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+)
+
+// LoadRules reads the rules from the JSON file
+func LoadRules(path string) (Rules, error) {
+	var rules Rules
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	err = json.Unmarshal(data, &rules)
+	return rules, err
+}
+
+// Watch reloads the rules whenever the file changes, so the admins can react to spam without redeploys.
+// It never returns, run it in a goroutine.
+func Watch(filter *RuleFilter, path string, interval time.Duration) {
+	var lastModified time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastModified = info.ModTime()
+	}
+
+	for {
+		time.Sleep(interval)
+		lastModified = reloadIfChanged(filter, path, lastModified)
+	}
+}
+
+func reloadIfChanged(filter *RuleFilter, path string, lastModified time.Time) time.Time {
+	info, err := os.Stat(path)
+	if err != nil || !info.ModTime().After(lastModified) {
+		return lastModified
+	}
+
+	//keep the old rules if the new file is not valid
+	rules, err := LoadRules(path)
+	if err == nil {
+		err = filter.SetRules(rules)
+	}
+	if err != nil {
+		log.Printf("content filter rules not reloaded: %v", err)
+		return info.ModTime()
+	}
+
+	log.Printf("content filter rules reloaded from %s", path)
+	return info.ModTime()
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -32,12 +34,16 @@ func BlockUser(c *gin.Context) {
 	//if they are friends, delete the friendship
 	if friendshipRequest.ID != "" {
 		models.DeleteFriend(initializers.DB, loggedInUserID, blockUser)
+		workers.Timelines.FriendshipRemoved(loggedInUserID, blockUser)
 	}
 
 	//block the user
 	ID := uuid.New().String()
 	models.BlockUser(initializers.DB, ID, loggedInUserID, blockUser)
 
+	//stop the live counts of the posts of each other
+	websocketrooms.Hub.UsersSeparated(loggedInUserID, blockUser)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User is successfully blocked.",
 	})
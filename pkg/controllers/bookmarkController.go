@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func SavePost(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//check whether the post exists and the logged in user can see it
+	post, err := models.GetPostByID(initializers.DB, c.Param("id"))
+	if err != nil || !models.CanViewPost(initializers.DB, loggedInUserID, post) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//Get the optional collection off req body
+	var body struct {
+		CollectionID string `json:"collection_id"`
+	}
+	if c.Request.ContentLength != 0 && c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//the post can be saved only into the collections of the logged in user
+	if body.CollectionID != "" {
+		collection, err := models.GetCollection(initializers.DB, body.CollectionID)
+		if err != nil || collection.UserID != loggedInUserID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Collection does not exist",
+			})
+			return
+		}
+	}
+
+	//save the post
+	if err := models.SavePost(initializers.DB, loggedInUserID, post.ID, body.CollectionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to save the post",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post is saved.",
+	})
+}
+
+func UnsavePost(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//remove the post from the saved posts
+	if err := models.UnsavePost(initializers.DB, loggedInUserID, c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to remove the saved post",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post is removed from the saved posts.",
+	})
+}
+
+func ShowSavedPosts(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//the collection is optional, all saved posts are shown without it
+	collectionID := c.Query("collection")
+	if collectionID != "" {
+		collection, err := models.GetCollection(initializers.DB, collectionID)
+		if err != nil || collection.UserID != loggedInUserID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Collection does not exist",
+			})
+			return
+		}
+	}
+
+	//get one page of the saved posts that the logged in user can still see
+	page, limit := getPagination(c)
+	posts, err := models.GetSavedPosts(initializers.DB, loggedInUserID, collectionID, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the saved posts",
+		})
+		return
+	}
+
+	//check if there is any post
+	if len(posts) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No saved posts yet.",
+		})
+		return
+	}
+
+	var postsInfo []models.PostAPI
+	for _, post := range posts {
+		postsInfo = append(postsInfo, models.PostInfo(post, loggedInUserID))
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, postsInfo)
+}
+
+func ShowCollections(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the collections of the logged in user
+	collections, err := models.GetCollections(initializers.DB, loggedInUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the collections",
+		})
+		return
+	}
+
+	//Respond
+	if len(collections) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No collections yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+func CreateCollection(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//Get the data off req body
+	var body struct {
+		Name string `json:"name"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Collection name is required.",
+		})
+		return
+	}
+
+	//create the collection
+	collection, err := models.CreateCollection(initializers.DB, loggedInUserID, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to create the collection",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, collection)
+}
+
+func DeleteCollection(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//only the owner can delete the collection
+	collection, err := models.GetCollection(initializers.DB, c.Param("id"))
+	if err != nil || collection.UserID != loggedInUserID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Collection does not exist",
+		})
+		return
+	}
+
+	//delete the collection, the posts stay saved
+	if err := models.DeleteCollection(initializers.DB, collection.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to delete the collection",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection is deleted.",
+	})
+}
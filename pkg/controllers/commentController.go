@@ -1,13 +1,28 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 
+	"github.com/dika-bosnjak/social-media-app/pkg/contentfilter"
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
 	"github.com/gin-gonic/gin"
 )
 
+// getCommentSort reads the order of the comments from the query, the oldest comments are first by default
+func getCommentSort(c *gin.Context) (string, bool) {
+	order := c.DefaultQuery("sort", models.CommentSortOldest)
+	if !models.ValidCommentSort(order) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Sort must be one of: oldest, top.",
+		})
+		return "", false
+	}
+	return order, true
+}
+
 func AddComment(c *gin.Context) {
 
 	//get post id from params
@@ -26,9 +41,18 @@ func AddComment(c *gin.Context) {
 	loggedInUser, _ := c.Get("user")
 	loggedInUserID := loggedInUser.(models.User).ID
 
+	//check whether the logged in user can comment the post
+	if !post.IsLive() || !models.CanInteract(initializers.DB, loggedInUserID, post.UserID, models.ActionComment) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
 	//Get the data off req body
 	var body struct {
 		CommentText string `json:"comment"`
+		ParentID    string `json:"parent_id"`
 	}
 	if c.Bind(&body) != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -37,8 +61,46 @@ func AddComment(c *gin.Context) {
 		return
 	}
 
+	//the reply must belong to a visible comment of the same post, and the thread can not be deeper than the limit
+	var parent models.Comment
+	if body.ParentID != "" {
+		parent, err = models.GetCommentByID(initializers.DB, body.ParentID)
+		if err != nil || parent.PostID != post.ID || parent.Hidden || parent.Deleted || !parent.Approved {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Comment you are replying to does not exist",
+			})
+			return
+		}
+		if parent.Depth >= models.MaxCommentDepth {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Maximum depth of replies is reached",
+			})
+			return
+		}
+	}
+
+	//check the text with the content filter
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindComment, body.CommentText)
+	if !ok {
+		return
+	}
+
+	//comments of the users restricted by the post owner wait for the approval
+	approved := !models.CheckRestrictStatus(initializers.DB, post.UserID, loggedInUserID)
+
 	//save the comment in the database
-	comment, err := models.CreateComment(initializers.DB, body.CommentText, loggedInUserID, post.ID)
+	comment := models.Comment{
+		UserID:   loggedInUserID,
+		PostID:   post.ID,
+		Text:     body.CommentText,
+		Approved: approved,
+		Hidden:   result.Verdict == contentfilter.Hold,
+	}
+	if body.ParentID != "" {
+		comment.ParentID = parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+	comment, err = models.CreateComment(initializers.DB, comment)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to comment the post",
@@ -46,13 +108,297 @@ func AddComment(c *gin.Context) {
 		return
 	}
 
-	//send the notification
-	models.SaveNotification(initializers.DB, post.UserID, loggedInUserID, "commented your post", "/post/"+postID)
+	//the comment held by the filter stays hidden until a moderator reviews it, its mentions are saved on the approval
+	if result.Verdict == contentfilter.Hold {
+		models.HoldForReview(initializers.DB, models.ReportTargetComment, comment.ID, loggedInUserID, "/post/"+postID, result.Reason)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Comment is held for review.",
+			"comment": comment,
+		})
+		return
+	}
+
+	//save the mentions of the comment, a failure is only logged because the comment is already saved
+	mentions, err := models.SaveMentions(initializers.DB, models.MentionTargetComment, comment.ID, comment.Text)
+	if err != nil {
+		log.Println("failed to save the mentions of the comment:", err)
+	}
+	commentAddedEffects(comment, parent, post, models.NewlyMentionedUsers(nil, mentions))
+
+	//Respond
+	c.JSON(http.StatusOK, comment)
+}
+
+// commentAddedEffects sends the notifications about the new comment, it runs when the comment is added
+// and when a moderator approves the comment held by the content filter
+func commentAddedEffects(comment models.Comment, parent models.Comment, post models.Post, mentioned []string) {
+
+	//restricted users do not notify the post owner
+	if comment.Approved {
+		websocketrooms.Hub.PostCountsChanged(initializers.DB, post.ID)
+		if comment.ParentID != "" && parent.UserID != comment.UserID {
+			models.SaveNotification(initializers.DB, parent.UserID, comment.UserID, "replied to your comment", "/post/"+post.ID)
+		}
+		if parent.UserID != post.UserID {
+			models.SaveNotification(initializers.DB, post.UserID, comment.UserID, "commented your post", "/post/"+post.ID)
+		}
+	}
+	notifyMentions(comment.UserID, mentioned, commentMentionViewer(comment, post), "mentioned you in a comment", "/post/"+post.ID)
+}
+
+func ShowCommentReplies(c *gin.Context) {
+
+	//get comment id
+	commentID := c.Param("id")
+
+	//get logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the comment and the post it belongs to
+	comment, err := models.GetCommentByID(initializers.DB, commentID)
+	if err != nil || comment.Hidden {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Comment does not exist",
+		})
+		return
+	}
+	post, err := models.GetPostByID(initializers.DB, comment.PostID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//check whether the logged in user can see the post
+	if !models.CanViewPost(initializers.DB, loggedInUserID, post) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//get one page of the replies in the requested order
+	order, ok := getCommentSort(c)
+	if !ok {
+		return
+	}
+	page, limit := getPagination(c)
+	replies, err := models.GetCommentReplies(initializers.DB, comment.ID, loggedInUserID, order, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the replies",
+		})
+		return
+	}
+
+	//Respond
+	if len(replies) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No replies yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, replies)
+}
+
+func UpdateComment(c *gin.Context) {
+
+	//get comment id
+	commentID := c.Param("id")
+
+	//get logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the comment from the database
+	comment, err := models.GetCommentByID(initializers.DB, commentID)
+	if err != nil || comment.Deleted {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Comment does not exist",
+		})
+		return
+	}
+
+	//only the author can edit the comment
+	if comment.UserID != loggedInUserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//get the post of the comment
+	post, err := models.GetPostByID(initializers.DB, comment.PostID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//the author blocked by the post owner after commenting can not edit the comment anymore
+	if !post.IsLive() || !models.CanInteract(initializers.DB, loggedInUserID, post.UserID, models.ActionComment) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//Get the data off req body
+	var body struct {
+		CommentText string `json:"comment"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//check the text with the content filter
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindComment, body.CommentText)
+	if !ok {
+		return
+	}
+
+	//update the comment, the previous text is kept in the history
+	previousMentions := models.GetMentions(initializers.DB, models.MentionTargetComment, comment.ID)
+	if result.Verdict == contentfilter.Hold {
+		comment.Hidden = true
+	}
+	comment, err = models.UpdateComment(initializers.DB, comment, body.CommentText)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update the comment",
+		})
+		return
+	}
+
+	//the comment held by the filter stays hidden until a moderator reviews it, the mentions of the new text are saved on the approval
+	if result.Verdict == contentfilter.Hold {
+		models.HoldForReview(initializers.DB, models.ReportTargetComment, comment.ID, loggedInUserID, "/post/"+comment.PostID, result.Reason)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Comment is held for review.",
+			"comment": comment,
+		})
+		return
+	}
+
+	//save the mentions of the new text, a failure is only logged because the comment is already saved
+	mentions, err := models.SaveMentions(initializers.DB, models.MentionTargetComment, comment.ID, comment.Text)
+	if err != nil {
+		log.Println("failed to save the mentions of the comment:", err)
+	}
+
+	//notify only the users that were not mentioned before the edit
+	notifyMentions(loggedInUserID, models.NewlyMentionedUsers(previousMentions, mentions), commentMentionViewer(comment, post), "mentioned you in a comment", "/post/"+comment.PostID)
 
 	//Respond
 	c.JSON(http.StatusOK, comment)
 }
 
+func ShowCommentRevisions(c *gin.Context) {
+
+	//get comment id
+	commentID := c.Param("id")
+
+	//get logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the comment from the database, the edit history of the deleted comment is kept for the moderators
+	isModerator := models.IsModerator(initializers.DB, loggedInUserID)
+	comment, err := models.GetCommentByID(initializers.DB, commentID)
+	if err != nil {
+		if !isModerator {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Comment does not exist",
+			})
+			return
+		}
+		comment = models.Comment{ID: commentID, Deleted: true}
+	}
+
+	//the edit history is visible only to the author and the moderators, the history of the deleted comment only to the moderators
+	if !isModerator && (comment.UserID != loggedInUserID || comment.Deleted) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//get the previous versions of the comment
+	revisions, err := models.GetCommentRevisions(initializers.DB, comment.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the edit history",
+		})
+		return
+	}
+
+	//Respond
+	if len(revisions) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No revisions yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"comment":   comment,
+		"revisions": revisions,
+	})
+}
+
+func ApproveComment(c *gin.Context) {
+
+	//get comment id
+	commentID := c.Param("id")
+
+	//get logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the comment and the post it belongs to
+	comment, err := models.GetCommentByID(initializers.DB, commentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Comment does not exist",
+		})
+		return
+	}
+	post, err := models.GetPostByID(initializers.DB, comment.PostID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//only the post owner can approve the comment
+	if post.UserID != loggedInUserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//approve the comment
+	if err := models.ApproveComment(initializers.DB, comment.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Comment could not be approved.",
+		})
+		return
+	}
+	websocketrooms.Hub.PostCountsChanged(initializers.DB, post.ID)
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment approved",
+	})
+}
+
 func DeleteComment(c *gin.Context) {
 
 	//get comment id
@@ -67,6 +413,7 @@ func DeleteComment(c *gin.Context) {
 
 	//if logged in user can delete a comment, delete it
 	if enableDelete {
+		comment, _ := models.GetCommentByID(initializers.DB, commentID)
 		err := models.DeleteComment(initializers.DB, commentID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -74,6 +421,7 @@ func DeleteComment(c *gin.Context) {
 			})
 			return
 		} else {
+			websocketrooms.Hub.PostCountsChanged(initializers.DB, comment.PostID)
 			c.JSON(http.StatusOK, gin.H{
 				"message": "Comment deleted",
 			})
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testCommentID = "80869e94-124c-4f17-96f7-f5283df7d909"
+
+func expectComment(mock sqlmock.Sqlmock, authorID string, text string, edited bool) {
+	mock.ExpectQuery("FROM comments").WithArgs(testCommentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "post_id", "parent_id", "depth", "text", "approved", "hidden", "deleted", "edited", "created_at", "updated_at"}).
+			AddRow(testCommentID, authorID, testPostID, "", 0, text, true, false, false, edited, time.Now(), time.Now()))
+}
+
+func TestUpdateComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Only the author can edit the comment",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectComment(mock, testOwnerID, "Old text", false)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Unauthorized.",
+		},
+		{
+			name: "Author edit keeps the previous text",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectComment(mock, testViewerID, "Old text", false)
+				//the comment on the own post
+				mock.ExpectQuery("FROM posts").WithArgs(testPostID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+						AddRow(testPostID, "", "Test post", testViewerID, "", false, "published", nil, nil, nil, time.Now(), time.Now()))
+				mock.ExpectQuery("FROM mentions").
+					WillReturnRows(sqlmock.NewRows([]string{"target_id", "user_id", "username", "offset", "length"}))
+				mock.ExpectExec("INSERT INTO comment_revisions").
+					WithArgs(sqlmock.AnyArg(), testCommentID, "Old text", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE comments").
+					WithArgs("New text", false, sqlmock.AnyArg(), testCommentID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectComment(mock, testViewerID, "New text", true)
+				mock.ExpectExec("DELETE FROM mentions").
+					WithArgs("comment", testCommentID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"edited":true`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPut, "/comment/"+testCommentID, strings.NewReader(`{"comment": "New text"}`))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestShowCommentRevisions(t *testing.T) {
+	expectModerator := func(mock sqlmock.Sqlmock, count int) {
+		mock.ExpectQuery("FROM moderators").WithArgs(testViewerID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+	expectRevisions := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("FROM comment_revisions").WithArgs(testCommentID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "comment_id", "text", "created_at"}).
+				AddRow("revision-id", testCommentID, "Deleted text", time.Now()))
+	}
+
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Author sees the edit history",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectModerator(mock, 0)
+				expectComment(mock, testViewerID, "New text", true)
+				expectRevisions(mock)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Deleted text",
+		},
+		{
+			name: "Author does not see the history of the deleted comment",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectModerator(mock, 0)
+				mock.ExpectQuery("FROM comments").WithArgs(testCommentID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "post_id", "parent_id", "depth", "text", "approved", "hidden", "deleted", "edited", "created_at", "updated_at"}).
+						AddRow(testCommentID, testViewerID, testPostID, "", 0, "This comment was deleted.", true, false, true, false, time.Now(), time.Now()))
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Unauthorized.",
+		},
+		{
+			name: "Moderator sees the history of the removed comment",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectModerator(mock, 1)
+				mock.ExpectQuery("FROM comments").WithArgs(testCommentID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				expectRevisions(mock)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Deleted text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/comment/"+testCommentID+"/revisions", nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/contentfilter"
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/gin-gonic/gin"
+)
+
+// checkContent runs the content filter on the text, when the text is rejected it responds with the error and returns false
+func checkContent(c *gin.Context, userID string, kind string, text string) (contentfilter.Result, bool) {
+	result := initializers.ContentFilter.Check(userID, kind, text)
+	if result.Verdict == contentfilter.Reject {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Content is rejected: " + result.Reason,
+		})
+		return result, false
+	}
+	return result, true
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func ShowExplore(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get one page of the popular posts of the public profiles, only with the hashtag when it is given
+	tag := models.NormalizeHashtag(c.Query("hashtag"))
+	page, limit := getPagination(c)
+	posts, err := models.GetExplorePosts(initializers.DB, loggedInUserID, tag, time.Now(), limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the posts",
+		})
+		return
+	}
+
+	//check if there is any post
+	if len(posts) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No posts yet.",
+		})
+		return
+	}
+
+	var postsInfo []models.PostAPI
+	for _, post := range posts {
+		postsInfo = append(postsInfo, models.PostInfo(post, loggedInUserID))
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, postsInfo)
+}
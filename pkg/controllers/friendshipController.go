@@ -6,6 +6,8 @@ import (
 
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/exp/slices"
 )
@@ -18,6 +20,14 @@ func AddFriend(c *gin.Context) {
 	loggedInUser, _ := c.Get("user")
 	loggedInUserID := loggedInUser.(models.User).ID
 
+	//check whether the added user accepts friend requests from the logged in user
+	if !models.CanInteract(initializers.DB, loggedInUserID, addedUser, models.ActionFriendRequest) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User can not be added as a friend.",
+		})
+		return
+	}
+
 	//record the friendship request
 	_, err := models.AddFriend(initializers.DB, loggedInUserID, addedUser)
 
@@ -53,6 +63,12 @@ func DeleteFriend(c *gin.Context) {
 		return
 	}
 
+	//remove the posts of the former friends from the timelines
+	workers.Timelines.FriendshipRemoved(loggedInUserID, userID)
+
+	//stop the live counts of the posts of each other, the client subscribes again to the public posts
+	websocketrooms.Hub.UsersSeparated(loggedInUserID, userID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Friend is deleted.",
 	})
@@ -116,6 +132,9 @@ func AcceptFriendshipRequest(c *gin.Context) {
 		return
 	}
 
+	//copy the posts of the new friends to the timelines
+	workers.Timelines.FriendshipAdded(user1, user2)
+
 	//send the notification
 	models.SaveNotification(initializers.DB, user1, user2, "accepted a friend request", "/user/"+user2)
 
@@ -210,6 +229,14 @@ func ShowFriends(c *gin.Context) {
 		searchUserID = loggedInUserID
 	}
 
+	//check whether the logged in user can see the friend list of the searched person
+	if !models.CanInteract(initializers.DB, loggedInUserID, searchUserID, models.ActionViewFriends) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Friend list is private.",
+		})
+		return
+	}
+
 	//get the friends of the searched person and blocked users for a logged in user
 	friends := models.GetFriends(initializers.DB, searchUserID)
 	blockedUsers, err := models.GetBlockedUsersID(initializers.DB, loggedInUserID)
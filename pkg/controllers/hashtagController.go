@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// trending hashtags are counted over the posts of the last hours, the window can be changed with the hours query
+const (
+	defaultTrendingHours = 24
+	maxTrendingHours     = 7 * 24
+)
+
+func ShowHashtagPosts(c *gin.Context) {
+
+	//get the hashtag from params
+	tag := models.NormalizeHashtag(c.Param("tag"))
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get one page of the posts with the hashtag that the logged in user can see
+	page, limit := getPagination(c)
+	posts, err := models.GetPostsByHashtag(initializers.DB, tag, loggedInUserID, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the posts",
+		})
+		return
+	}
+
+	//check if there is any post
+	if len(posts) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No posts yet.",
+		})
+		return
+	}
+
+	var postsInfo []models.PostAPI
+	for _, post := range posts {
+		postsInfo = append(postsInfo, models.PostInfo(post, loggedInUserID))
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, postsInfo)
+}
+
+func ShowTrendingHashtags(c *gin.Context) {
+
+	//get the size of the sliding window
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", strconv.Itoa(defaultTrendingHours)))
+	if err != nil || hours < 1 || hours > maxTrendingHours {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hours must be between 1 and " + strconv.Itoa(maxTrendingHours) + ".",
+		})
+		return
+	}
+
+	//get the most used hashtags in the window
+	_, limit := getPagination(c)
+	hashtags, err := models.GetTrendingHashtags(initializers.DB, time.Now().Add(-time.Duration(hours)*time.Hour), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the trending hashtags",
+		})
+		return
+	}
+
+	//Respond
+	if len(hashtags) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No hashtags yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, hashtags)
+}
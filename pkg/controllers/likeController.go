@@ -5,6 +5,7 @@ import (
 
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,62 +28,116 @@ func LikePost(c *gin.Context) {
 	loggedInUser, _ := c.Get("user")
 	loggedInUserID := loggedInUser.(models.User).ID
 
-	//check whether this user already liked post
-	like, err := models.CheckLike(initializers.DB, postID, loggedInUserID)
+	//check whether the logged in user can like the post
+	if !post.IsLive() || !models.CanInteract(initializers.DB, loggedInUserID, post.UserID, models.ActionLike) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//like the post, or remove the like if the post is already liked
+	reacted, err := toggleReaction(loggedInUserID, models.ReactionTargetPost, postID, models.ReactionLike)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err,
+			"error": "Failed to like the post",
 		})
 		return
 	}
+	websocketrooms.Hub.PostCountsChanged(initializers.DB, post.ID)
+	if !reacted {
+		//Respond
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Successfully disliked post",
+		})
+		return
+	}
+	models.SaveNotification(initializers.DB, post.UserID, loggedInUserID, "liked your post", "/post/"+postID)
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully liked post",
+	})
+}
+
+// LikeComment works as a toggle function like LikePost
+func LikeComment(c *gin.Context) {
 
-	if like.ID != "" {
-		if like.LikeBool {
-			//if there is already a record in the database, switch to dislike
-			err := models.UpdateLike(initializers.DB, like, false)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "Failed to unlike the post",
-				})
-				return
-			}
-			//Respond
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Successfully disliked post",
-			})
-			return
-		} else {
-			//if there is already a record in the database, switch to like
-			err := models.UpdateLike(initializers.DB, like, true)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "Failed to like the post",
-				})
-				return
-			}
-			models.SaveNotification(initializers.DB, post.UserID, loggedInUserID, "liked your post", "/post/"+postID)
-			//Respond
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Successfully liked post",
-			})
-			return
-		}
-	} else {
-		//if there is no previous record, crate a new one
-		_, err := models.LikePost(initializers.DB, loggedInUserID, postID)
-
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Failed to like the post",
-			})
-			return
-		}
-		models.SaveNotification(initializers.DB, post.UserID, loggedInUserID, "liked your post", "/post/"+postID)
+	//get user id from logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
 
+	//check whether the logged in user can like the comment
+	comment, _, ok := getCommentForInteraction(c, loggedInUserID)
+	if !ok {
+		return
+	}
+
+	//like the comment, or remove the like if the comment is already liked
+	reacted, err := toggleReaction(loggedInUserID, models.ReactionTargetComment, comment.ID, models.ReactionLike)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to like the comment",
+		})
+		return
+	}
+	if !reacted {
 		//Respond
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Successfully liked post",
+			"message": "Successfully disliked comment",
+		})
+		return
+	}
+	models.SaveNotification(initializers.DB, comment.UserID, loggedInUserID, "liked your comment", "/post/"+comment.PostID)
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully liked comment",
+	})
+}
+
+func ShowPostLikes(c *gin.Context) {
+
+	//get post id from params
+	postID := c.Param("id")
+
+	//check whether this post exists
+	post, err := models.GetPostByID(initializers.DB, postID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//get user id from logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//check whether the logged in user can see the post
+	if !models.CanViewPost(initializers.DB, loggedInUserID, post) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "This account is private.",
+		})
+		return
+	}
+
+	//get one page of the users that liked the post
+	page, limit := getPagination(c)
+	likers, err := models.GetPostLikers(initializers.DB, post.ID, loggedInUserID, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the likes",
+		})
+		return
+	}
+
+	//Respond
+	if len(likers) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No likes yet.",
 		})
 		return
 	}
+	c.JSON(http.StatusOK, likers)
 }
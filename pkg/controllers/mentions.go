@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+// notifyMentions notifies the mentioned users that can see the content, SaveNotification leaves out the users that blocked or muted the author
+func notifyMentions(authorID string, userIDs []string, canSee func(userID string) bool, message string, url string) {
+	for _, userID := range userIDs {
+		if canSee(userID) {
+			models.SaveNotification(initializers.DB, userID, authorID, message, url)
+		}
+	}
+}
+
+// postMentionViewer checks whether the mentioned user can see the post
+func postMentionViewer(post models.Post) func(userID string) bool {
+	return func(userID string) bool {
+		return models.CanViewPost(initializers.DB, userID, post)
+	}
+}
+
+// commentMentionViewer checks whether the mentioned user can see the comment, the comments waiting for the approval are visible only to the post owner
+func commentMentionViewer(comment models.Comment, post models.Post) func(userID string) bool {
+	return func(userID string) bool {
+		if !comment.Approved && userID != post.UserID {
+			return false
+		}
+		return models.CanViewPost(initializers.DB, userID, post)
+	}
+}
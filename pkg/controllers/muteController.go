@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func MuteUser(c *gin.Context) {
+
+	//get the searched user id from params
+	muteUser := c.Param("id")
+
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	if muteUser == loggedInUserID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User can not mute himself.",
+		})
+		return
+	}
+
+	//check whether the user is already muted
+	if models.CheckMuteStatus(initializers.DB, loggedInUserID, muteUser) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "User is already muted.",
+		})
+		return
+	}
+
+	//mute the user (the muted user is not notified about it)
+	ID := uuid.New().String()
+	if err := models.MuteUser(initializers.DB, ID, loggedInUserID, muteUser); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User could not be muted.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User is successfully muted.",
+	})
+}
+
+func UnmuteUser(c *gin.Context) {
+
+	//get the searched user id from params
+	userID := c.Param("id")
+
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the mute, and then unmute the person
+	mute, err := models.GetMute(initializers.DB, loggedInUserID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err,
+		})
+		return
+	}
+	if err := models.Unmute(initializers.DB, mute.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User is unmuted.",
+	})
+}
+
+func ShowMutedUsers(c *gin.Context) {
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	mutedUsers, err := models.GetMutedUsers(initializers.DB, loggedInUserID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	//send json with all muted users (sorted) or display a message
+	if len(mutedUsers) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No muted users yet.",
+		})
+		return
+	} else {
+		sort.Slice(mutedUsers, func(p, q int) bool {
+			return mutedUsers[p].UserMutedFirstName < mutedUsers[q].UserMutedFirstName
+		})
+
+		c.JSON(http.StatusOK, mutedUsers)
+		return
+	}
+}
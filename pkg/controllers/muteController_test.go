@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMuteAndRestrictUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "User can not mute himself",
+			path:       "/user/" + testViewerID + "/mute",
+			expect:     func(mock sqlmock.Sqlmock) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "User can not mute himself.",
+		},
+		{
+			name: "Muted user is not muted twice",
+			path: "/user/" + testOwnerID + "/mute",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM mutes").WithArgs(testViewerID, testOwnerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_mute_id", "user_muted_id", "created_at", "updated_at"}).
+						AddRow("mute-id", testViewerID, testOwnerID, time.Now(), time.Now()))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "User is already muted.",
+		},
+		{
+			name: "User is muted",
+			path: "/user/" + testOwnerID + "/mute",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM mutes").WithArgs(testViewerID, testOwnerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_mute_id", "user_muted_id", "created_at", "updated_at"}))
+				mock.ExpectExec("INSERT INTO mutes").WithArgs(sqlmock.AnyArg(), testViewerID, testOwnerID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "User is successfully muted.",
+		},
+		{
+			name: "User is restricted",
+			path: "/user/" + testOwnerID + "/restrict",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM restricts").WithArgs(testViewerID, testOwnerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_restrict_id", "user_restricted_id", "created_at", "updated_at"}))
+				mock.ExpectExec("INSERT INTO restricts").WithArgs(sqlmock.AnyArg(), testViewerID, testOwnerID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "User is successfully restricted.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/search"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testViewerID = "40469e94-124c-4f17-96f7-f5283df7d707"
+	testOwnerID  = "50569e94-124c-4f17-96f7-f5283df7d505"
+	testPostID   = "60669e94-124c-4f17-96f7-f5283df7d606"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	initializers.DB = sqlDB
+	t.Cleanup(func() { sqlDB.Close() })
+
+	//replace the auth middleware with the logged in viewer
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	loggedIn := func(c *gin.Context) {
+		c.Set("user", models.User{ID: testViewerID})
+		c.Next()
+	}
+
+	r.GET("/users", loggedIn, SearchUser)
+	r.GET("/user/:id", loggedIn, DisplayProfile)
+	r.GET("/user/:id/posts", loggedIn, DisplayPostsByUserId)
+	r.GET("/user/:id/friends", loggedIn, ShowFriends)
+	r.POST("/user/:id/add", loggedIn, AddFriend)
+	r.GET("/post/:id", loggedIn, DisplayPost)
+	r.POST("/post/:id/like", loggedIn, LikePost)
+	r.GET("/post/:id/likes", loggedIn, ShowPostLikes)
+	r.POST("/post/:id/repost", loggedIn, RepostPost)
+	r.GET("/user/saved", loggedIn, ShowSavedPosts)
+	r.POST("/post/:id/pin", loggedIn, PinPost)
+	r.GET("/home", loggedIn, DisplayPostsOnHomePage)
+	r.POST("/post/:id/comment", loggedIn, AddComment)
+	r.GET("/chatroom/:userID", loggedIn, OpenChatRoom)
+	r.PUT("/comment/:id", loggedIn, UpdateComment)
+	r.GET("/comment/:id/revisions", loggedIn, ShowCommentRevisions)
+	r.POST("/user/:id/mute", loggedIn, MuteUser)
+	r.POST("/user/:id/restrict", loggedIn, RestrictUser)
+	r.POST("/report", loggedIn, ReportContent)
+	r.POST("/moderation/reports/:type/:targetID/action", loggedIn, ActionReports)
+	r.POST("/moderation/reports/:type/:targetID/dismiss", loggedIn, DismissReports)
+
+	return r, mock
+}
+
+func expectPost(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM posts").WithArgs(testPostID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+			AddRow(testPostID, "", "Test post", testOwnerID, "", false, "published", nil, nil, nil, time.Now(), time.Now()))
+}
+
+func expectBlock(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM blocks").WithArgs(testViewerID, testOwnerID, testOwnerID, testViewerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("70769e94-124c-4f17-96f7-f5283df7d808"))
+}
+
+func TestBlockedUserRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Blocked user is not in the search results",
+			method:     http.MethodGet,
+			path:       "/users?search=Dika",
+			wantStatus: http.StatusOK,
+			wantBody:   "There is no user with that username.",
+			expect: func(mock sqlmock.Sqlmock) {
+				initializers.SearchIndex = search.NewMemoryIndex()
+				initializers.SearchIndex.Index(search.Document{Kind: search.KindUser, ID: testOwnerID, Fields: []search.Field{{Name: "name", Text: "Dika Bosnjak", Boost: 1}}})
+				//the blocks of all found users are checked in one query
+				mock.ExpectQuery("FROM users .* FROM blocks").
+					WithArgs(testViewerID, testViewerID, testViewerID, testOwnerID, testViewerID, testViewerID, testViewerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "first_name", "last_name", "profile_description", "user_photo_url", "profile_type", "description_visible"}))
+			},
+		},
+		{
+			name:       "Blocked user can not see the profile",
+			method:     http.MethodGet,
+			path:       "/user/" + testOwnerID,
+			wantStatus: http.StatusOK,
+			wantBody:   "Profile could not be displayed.",
+			expect:     expectBlock,
+		},
+		{
+			name:       "Blocked user can not see the posts of the user",
+			method:     http.MethodGet,
+			path:       "/user/" + testOwnerID + "/posts",
+			wantStatus: http.StatusUnauthorized,
+			expect:     expectBlock,
+		},
+		{
+			name:       "Blocked user can not see the friends of the user",
+			method:     http.MethodGet,
+			path:       "/user/" + testOwnerID + "/friends",
+			wantStatus: http.StatusUnauthorized,
+			expect:     expectBlock,
+		},
+		{
+			name:       "Blocked user can not send a friend request",
+			method:     http.MethodPost,
+			path:       "/user/" + testOwnerID + "/add",
+			wantStatus: http.StatusUnauthorized,
+			expect:     expectBlock,
+		},
+		{
+			name:       "Blocked user can not see the post",
+			method:     http.MethodGet,
+			path:       "/post/" + testPostID,
+			wantStatus: http.StatusUnauthorized,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+				expectBlock(mock)
+			},
+		},
+		{
+			name:       "Blocked user can not like the post",
+			method:     http.MethodPost,
+			path:       "/post/" + testPostID + "/like",
+			wantStatus: http.StatusUnauthorized,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+				expectBlock(mock)
+			},
+		},
+		{
+			name:       "Blocked user can not see who liked the post",
+			method:     http.MethodGet,
+			path:       "/post/" + testPostID + "/likes",
+			wantStatus: http.StatusUnauthorized,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+				expectBlock(mock)
+			},
+		},
+		{
+			name:       "Blocked user can not share the post",
+			method:     http.MethodPost,
+			path:       "/post/" + testPostID + "/repost",
+			wantStatus: http.StatusUnauthorized,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+				expectBlock(mock)
+			},
+		},
+		{
+			name:       "Saved post of the user that blocked the viewer disappears",
+			method:     http.MethodGet,
+			path:       "/user/saved",
+			wantStatus: http.StatusOK,
+			wantBody:   "No saved posts yet.",
+			expect: func(mock sqlmock.Sqlmock) {
+				//the blocks are checked in the query, before the page is cut
+				mock.ExpectQuery("FROM bookmarks .* FROM blocks WHERE user_block_id = \\? UNION SELECT user_block_id FROM blocks WHERE user_blocked_id = \\?\\) ORDER BY").
+					WithArgs(testViewerID, "", "", testViewerID, testViewerID, testViewerID, testViewerID, testViewerID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}))
+			},
+		},
+		{
+			name:       "Blocked user can not comment the post",
+			method:     http.MethodPost,
+			path:       "/post/" + testPostID + "/comment",
+			body:       `{"comment": "Test comment"}`,
+			wantStatus: http.StatusUnauthorized,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+				expectBlock(mock)
+			},
+		},
+		{
+			name:       "Blocked user can not edit the comment on the post",
+			method:     http.MethodPut,
+			path:       "/comment/" + testCommentID,
+			body:       `{"comment": "New text"}`,
+			wantStatus: http.StatusUnauthorized,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectComment(mock, testViewerID, "Old text", false)
+				expectPost(mock)
+				expectBlock(mock)
+			},
+		},
+		{
+			name:       "Blocked user can not open the chat room",
+			method:     http.MethodGet,
+			path:       "/chatroom/" + testOwnerID,
+			wantStatus: http.StatusUnauthorized,
+			expect:     expectBlock,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %v, want %v (body %s)", tt.method, tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("%s %s body = %s, want %s", tt.method, tt.path, w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("%s %s: %v", tt.method, tt.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+)
+
+// pollBody is the poll sent together with the new post
+type pollBody struct {
+	Options        []string   `json:"options"`
+	MultipleChoice bool       `json:"multiple_choice"`
+	HideResults    bool       `json:"hide_results"`
+	EndsAt         *time.Time `json:"ends_at"`
+}
+
+// validatePoll checks the options and the end time of the poll, when the poll is not valid it responds with the error and returns false
+func validatePoll(c *gin.Context, poll *pollBody, publishAt *time.Time) bool {
+
+	//the options can not be empty or repeated
+	var options []string
+	for _, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || slices.IndexFunc(options, func(other string) bool { return strings.EqualFold(option, other) }) != -1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Poll options can not be empty or repeated.",
+			})
+			return false
+		}
+		options = append(options, option)
+	}
+	if len(options) < models.MinPollOptions || len(options) > models.MaxPollOptions {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Poll must have from 2 to 6 options.",
+		})
+		return false
+	}
+	poll.Options = options
+
+	//the poll must end after the post is published
+	start := time.Now()
+	if publishAt != nil {
+		start = *publishAt
+	}
+	if poll.EndsAt != nil && !poll.EndsAt.After(start) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Poll must end after the post is published.",
+		})
+		return false
+	}
+	return true
+}
+
+func VotePoll(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//the poll can be voted by everyone who can see the post
+	post, err := models.GetPostByID(initializers.DB, c.Param("id"))
+	if err != nil || !post.IsLive() || !models.CanViewPost(initializers.DB, loggedInUserID, post) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+	poll, err := models.GetPollByPostID(initializers.DB, post.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not have a poll",
+		})
+		return
+	}
+	if poll.IsClosed(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Poll is closed.",
+		})
+		return
+	}
+
+	//Get the data off req body
+	var body struct {
+		OptionIDs []string `json:"option_ids"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//check the chosen options
+	options, err := models.GetPollOptions(initializers.DB, poll.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the poll options",
+		})
+		return
+	}
+	if !models.ValidPollVote(poll, options, body.OptionIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Choose one option, or more different options if the poll allows multiple choices.",
+		})
+		return
+	}
+
+	//save the vote, it replaces the previous vote of the logged in user
+	if err := models.VotePoll(initializers.DB, poll.ID, loggedInUserID, body.OptionIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to vote",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, models.PollInfo(initializers.DB, post, loggedInUserID, time.Now()))
+}
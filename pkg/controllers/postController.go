@@ -1,20 +1,36 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dika-bosnjak/social-media-app/pkg/contentfilter"
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// modes of the home feed, the ranked feed is the default and the latest feed shows the posts of the friends newest first
+const (
+	feedModeRanked = "ranked"
+	feedModeLatest = "latest"
+)
+
 func CreatePost(c *gin.Context) {
 
 	//Get the data off req body
 	var body struct {
-		Photo string `json:"photo_url"`
-		Text  string `json:"text"`
+		Photo     string     `json:"photo_url"`
+		Text      string     `json:"text"`
+		Status    string     `json:"status"`
+		PublishAt *time.Time `json:"publish_at"`
+		Poll      *pollBody  `json:"poll"`
 	}
 	if c.Bind(&body) != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -23,13 +39,55 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	//the post is published right away, saved as a draft or scheduled for the future
+	switch body.Status {
+	case "", models.PostStatusPublished, models.PostStatusDraft:
+		body.PublishAt = nil
+	case models.PostStatusScheduled:
+		if body.PublishAt == nil || !body.PublishAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Scheduled post needs a publish time in the future.",
+			})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status must be one of: published, draft, scheduled.",
+		})
+		return
+	}
+
+	//check the poll attached to the post
+	if body.Poll != nil && !validatePoll(c, body.Poll, body.PublishAt) {
+		return
+	}
+
 	//Create the post
 	postID := uuid.New().String()
 	loggedInUser, _ := c.Get("user")
 	loggedInUserID := loggedInUser.(models.User).ID
 
-	post := models.Post{ID: postID, UserID: loggedInUserID, Photo: body.Photo, Text: body.Text}
-	post, err := models.CreatePost(initializers.DB, post)
+	//check the text and the poll options with the content filter
+	text := body.Text
+	if body.Poll != nil {
+		text = text + "\n" + strings.Join(body.Poll.Options, "\n")
+	}
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindPost, text)
+	if !ok {
+		return
+	}
+
+	//the post held by the filter stays hidden until a moderator reviews it
+	post := models.Post{ID: postID, UserID: loggedInUserID, Photo: body.Photo, Text: body.Text, Status: body.Status, PublishAt: body.PublishAt, Hidden: result.Verdict == contentfilter.Hold}
+
+	//the post and its poll are saved together
+	var poll *models.Poll
+	var options []string
+	if body.Poll != nil {
+		poll = &models.Poll{MultipleChoice: body.Poll.MultipleChoice, HideResults: body.Poll.HideResults, EndsAt: body.Poll.EndsAt}
+		options = body.Poll.Options
+	}
+	post, _, err := models.CreatePostWithPoll(initializers.DB, post, poll, options)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to create post",
@@ -37,10 +95,267 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	//write the published post to the timelines of the friends, the held post stays hidden in them until it is approved
+	if post.Status == models.PostStatusPublished {
+		workers.Timelines.PostPublished(post.ID)
+	}
+
+	//the mentions of the held post are saved and notified when a moderator approves it
+	if post.Hidden {
+		models.HoldForReview(initializers.DB, models.ReportTargetPost, post.ID, loggedInUserID, "/post/"+post.ID, result.Reason)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Post is held for review.",
+			"post":    post,
+		})
+		return
+	}
+
+	//save the mentions of the post, a failure is only logged because the post is already saved
+	mentions, err := models.SaveMentions(initializers.DB, models.MentionTargetPost, post.ID, post.Text)
+	if err != nil {
+		log.Println("failed to save the mentions of the post:", err)
+	}
+	postPublishedEffects(post, models.NewlyMentionedUsers(nil, mentions))
+
+	//Respond
+	c.JSON(http.StatusOK, post)
+}
+
+// postPublishedEffects fetches the link preview of the new post and lets the users know about it, it runs when the post
+// is created and when a moderator approves the post held by the content filter
+func postPublishedEffects(post models.Post, mentioned []string) {
+
+	//fetch the preview of the link in the background
+	workers.LinkPreviews.Enqueue(post.Text)
+
+	//the mentioned users of the drafts and the scheduled posts are notified when the post is published
+	if post.Status != models.PostStatusPublished {
+		return
+	}
+	if post.RepostOfID != "" {
+		if original, err := models.GetPostByID(initializers.DB, post.RepostOfID); err == nil {
+			models.SaveNotification(initializers.DB, original.UserID, post.UserID, "shared your post", "/post/"+post.ID)
+		}
+	}
+	notifyMentions(post.UserID, mentioned, postMentionViewer(post), "mentioned you in a post", "/post/"+post.ID)
+	websocketrooms.Hub.PostPublished(initializers.DB, post)
+}
+
+// getUnpublishedPost gets the draft or the scheduled post of the logged in user
+func getUnpublishedPost(c *gin.Context, userID string) (models.Post, bool) {
+	post, err := models.GetPostByID(initializers.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The post is not found.",
+		})
+		return post, false
+	}
+	if post.UserID != userID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return post, false
+	}
+	if post.Status == models.PostStatusPublished {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The post is already published.",
+		})
+		return post, false
+	}
+	return post, true
+}
+
+func PublishPost(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the draft or the scheduled post
+	post, ok := getUnpublishedPost(c, loggedInUserID)
+	if !ok {
+		return
+	}
+
+	//publish the post now
+	now := time.Now()
+	published, err := models.PublishPost(initializers.DB, post.ID, now)
+	if err != nil || !published {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to publish the post",
+		})
+		return
+	}
+	post.Status = models.PostStatusPublished
+	post.PublishAt = nil
+	post.CreatedAt = now
+	workers.Timelines.PostPublished(post.ID)
+
+	//notify the friends and the mentioned users
+	if !post.Hidden {
+		models.NotifyPublishedPost(initializers.DB, post)
+		websocketrooms.Hub.PostPublished(initializers.DB, post)
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, post)
+}
+
+func SchedulePost(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the draft or the scheduled post
+	post, ok := getUnpublishedPost(c, loggedInUserID)
+	if !ok {
+		return
+	}
+
+	//Get the data off req body
+	var body struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+	if body.PublishAt == nil || !body.PublishAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Scheduled post needs a publish time in the future.",
+		})
+		return
+	}
+
+	//schedule the post
+	if err := models.SchedulePost(initializers.DB, post.ID, *body.PublishAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to schedule the post",
+		})
+		return
+	}
+	post.Status = models.PostStatusScheduled
+	post.PublishAt = body.PublishAt
+
 	//Respond
 	c.JSON(http.StatusOK, post)
 }
 
+func ShowDrafts(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the drafts and the scheduled posts
+	posts, err := models.GetDraftPosts(initializers.DB, loggedInUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the drafts",
+		})
+		return
+	}
+
+	//Respond
+	if len(posts) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No drafts yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, posts)
+}
+
+func RepostPost(c *gin.Context) {
+
+	//get the shared post from the database
+	original, err := models.GetPostByID(initializers.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The post is not found.",
+		})
+		return
+	}
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//the repost always points to the first post, not to another repost
+	if original.RepostOfID != "" {
+		original, err = models.GetPostByID(initializers.DB, original.RepostOfID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "The post is not available.",
+			})
+			return
+		}
+	}
+
+	//only the posts that the logged in user can see can be shared
+	if !models.CanViewPost(initializers.DB, loggedInUserID, original) || !original.IsLive() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//Get the optional text off req body
+	var body struct {
+		Text string `json:"text"`
+	}
+	if c.Request.ContentLength != 0 && c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//check the text with the content filter
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindPost, body.Text)
+	if !ok {
+		return
+	}
+
+	//create the repost
+	post := models.Post{ID: uuid.New().String(), UserID: loggedInUserID, Text: body.Text, RepostOfID: original.ID, Hidden: result.Verdict == contentfilter.Hold}
+	post, err = models.CreatePost(initializers.DB, post)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to share the post",
+		})
+		return
+	}
+
+	//write the repost to the timelines of the friends
+	workers.Timelines.PostPublished(post.ID)
+
+	//the mentions of the held repost are saved and notified when a moderator approves it
+	if post.Hidden {
+		models.HoldForReview(initializers.DB, models.ReportTargetPost, post.ID, loggedInUserID, "/post/"+post.ID, result.Reason)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Post is held for review.",
+			"post":    post,
+		})
+		return
+	}
+
+	//save the mentions of the added text, a failure is only logged because the post is already saved
+	mentions, err := models.SaveMentions(initializers.DB, models.MentionTargetPost, post.ID, post.Text)
+	if err != nil {
+		log.Println("failed to save the mentions of the post:", err)
+	}
+
+	//send the notifications
+	postPublishedEffects(post, models.NewlyMentionedUsers(nil, mentions))
+
+	//Respond
+	c.JSON(http.StatusOK, models.PostInfo(post, loggedInUserID))
+}
+
 func DisplayPost(c *gin.Context) {
 
 	//get postID from request
@@ -60,15 +375,21 @@ func DisplayPost(c *gin.Context) {
 	loggedInUserID := loggedInUser.(models.User).ID
 
 	//check whether the logged in user can view post
-	enablePostView := models.EnablePostView(loggedInUserID, post.UserID)
-	if !enablePostView {
+	if !models.CanViewPost(initializers.DB, loggedInUserID, post) {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "This account is private.",
 		})
 		return
 	}
 
+	//get the order of the comments
+	order, ok := getCommentSort(c)
+	if !ok {
+		return
+	}
+
 	postInfo := models.PostInfo(post, loggedInUserID)
+	postInfo.Comments = models.SortCommentTree(postInfo.Comments, order)
 	c.JSON(http.StatusOK, postInfo)
 }
 
@@ -108,7 +429,14 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	//check the text with the content filter
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindPost, body.Text)
+	if !ok {
+		return
+	}
+
 	//Update the post
+	previousMentions := models.GetMentions(initializers.DB, models.MentionTargetPost, post.ID)
 	post, err = models.UpdatePost(initializers.DB, post, body.Text, body.Photo)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -117,10 +445,84 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	//the post held by the filter stays hidden until a moderator reviews it, the mentions of the new text are saved on the approval
+	if result.Verdict == contentfilter.Hold {
+		models.SetPostHidden(initializers.DB, post.ID, true)
+		models.HoldForReview(initializers.DB, models.ReportTargetPost, post.ID, loggedInUserID, "/post/"+post.ID, result.Reason)
+		post.Hidden = true
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Post is held for review.",
+			"post":    post,
+		})
+		return
+	}
+
+	//save the mentions of the new text, a failure is only logged because the post is already saved
+	mentions, err := models.SaveMentions(initializers.DB, models.MentionTargetPost, post.ID, post.Text)
+	if err != nil {
+		log.Println("failed to save the mentions of the post:", err)
+	}
+	postEditedEffects(post, models.NewlyMentionedUsers(previousMentions, mentions))
+
 	//Respond
 	c.JSON(http.StatusOK, post)
 }
 
+// postEditedEffects fetches the preview of the new link and notifies only the users that were not mentioned before the edit,
+// it runs when the post is edited and when a moderator approves the edit held by the content filter
+func postEditedEffects(post models.Post, mentioned []string) {
+	workers.LinkPreviews.Enqueue(post.Text)
+	notifyMentions(post.UserID, mentioned, postMentionViewer(post), "mentioned you in a post", "/post/"+post.ID)
+}
+
+func ShowPostRevisions(c *gin.Context) {
+
+	//get postID from request
+	postID := c.Param("id")
+
+	//get the post from the database
+	post, err := models.GetPostByID(initializers.DB, postID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The post is not found.",
+		})
+		return
+	}
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//the edit history is visible to everyone who can view the post
+	if !models.CanViewPost(initializers.DB, loggedInUserID, post) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "This account is private.",
+		})
+		return
+	}
+
+	//get the previous versions of the post
+	revisions, err := models.GetPostRevisions(initializers.DB, post.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the edit history",
+		})
+		return
+	}
+
+	//Respond
+	if len(revisions) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No revisions yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"post":      post,
+		"revisions": models.PostRevisionDiffs(post, revisions),
+	})
+}
+
 func DeletePost(c *gin.Context) {
 	//get post id from request
 	postID := c.Param("id")
@@ -160,6 +562,76 @@ func DeletePost(c *gin.Context) {
 	})
 }
 
+// setPostPinned pins the post of the logged in user to the top of the profile or unpins it
+func setPostPinned(c *gin.Context, pinned bool) {
+
+	//get the post from the database
+	post, err := models.GetPostByID(initializers.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The post is not found.",
+		})
+		return
+	}
+
+	//check whether the logged in user is the author of the post
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+	if loggedInUserID != post.UserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	//only the visible posts can be pinned, and only up to the limit
+	if pinned {
+		if !post.IsLive() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Only published posts can be pinned.",
+			})
+			return
+		}
+		if post.PinnedAt != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Post is already pinned.",
+			})
+			return
+		}
+		if models.GetNumberOfPinnedPosts(initializers.DB, loggedInUserID) >= models.MaxPinnedPosts {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "You can pin up to " + strconv.Itoa(models.MaxPinnedPosts) + " posts.",
+			})
+			return
+		}
+	}
+
+	//pin or unpin the post
+	if err := models.SetPostPinned(initializers.DB, post.ID, pinned); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update the post",
+		})
+		return
+	}
+
+	//Respond
+	message := "Post is pinned."
+	if !pinned {
+		message = "Post is unpinned."
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+func PinPost(c *gin.Context) {
+	setPostPinned(c, true)
+}
+
+func UnpinPost(c *gin.Context) {
+	setPostPinned(c, false)
+}
+
 func DisplayPostsByUserId(c *gin.Context) {
 	//get userID from request
 	userID := c.Param("id")
@@ -169,8 +641,7 @@ func DisplayPostsByUserId(c *gin.Context) {
 	loggedInUserID := loggedInUser.(models.User).ID
 
 	//check whether the logged in user can see the posts
-	enablePostView := models.EnablePostView(loggedInUserID, userID)
-	if !enablePostView {
+	if !models.CanInteract(initializers.DB, loggedInUserID, userID, models.ActionViewPost) {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "This account is private.",
 		})
@@ -205,17 +676,72 @@ func DisplayPostsByUserId(c *gin.Context) {
 	}
 }
 
+// rankedAtHeader returns the time the ranked feed was ranked with, the client sends it back as ranked_at for the next pages
+const rankedAtHeader = "X-Ranked-At"
+
+// getRankedAt reads the time of the first page of the ranked feed from the query, the first page is ranked with the current time
+func getRankedAt(c *gin.Context) (time.Time, bool) {
+	now := time.Now()
+	rankedAt := c.Query("ranked_at")
+	if rankedAt == "" {
+		return time.Unix(now.Unix(), 0), true
+	}
+	seconds, err := strconv.ParseInt(rankedAt, 10, 64)
+	if err != nil || seconds > now.Unix() || seconds < now.Add(-models.FeedCandidateAge).Unix() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Ranked at must be the unix time of the first page from the last week.",
+		})
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
 func DisplayPostsOnHomePage(c *gin.Context) {
 
 	//get the logged in user info
 	loggedInUser, _ := c.Get("user")
 	loggedInUserID := loggedInUser.(models.User).ID
 
-	//get friends ids of the logged in user
-	friends := models.GetFriendsIDs(initializers.DB, loggedInUserID)
+	//the feed is ranked unless the chronological view is requested
+	mode := c.DefaultQuery("mode", feedModeRanked)
+	if mode != feedModeRanked && mode != feedModeLatest {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Mode must be one of: ranked, latest.",
+		})
+		return
+	}
+
+	//the posts of the friends are read from the timeline of the logged in user, without the muted users
+	var posts []models.Post
+	var err error
+	page, limit := getPagination(c)
+	offset := (page - 1) * limit
+	switch mode {
+	case feedModeLatest:
+		//read the requested page of the timeline, the newest first
+		posts, err = models.GetTimelinePosts(initializers.DB, loggedInUserID, time.Time{}, offset+limit)
+	case feedModeRanked:
+		//rank the recent posts of the friends and the own posts, the next pages are ranked with the time of the first page
+		now, ok := getRankedAt(c)
+		if !ok {
+			return
+		}
+		c.Header(rankedAtHeader, strconv.FormatInt(now.Unix(), 10))
+		posts, err = models.GetFeedCandidates(initializers.DB, loggedInUserID, now)
+		if err == nil {
+			posts, err = models.RankFeed(initializers.DB, posts, loggedInUserID, now)
+		}
+	}
 
-	//find friends posts in the database
-	posts, err := models.GetPostsByAuthors(initializers.DB, friends)
+	//return the requested page
+	if offset < len(posts) {
+		posts = posts[offset:]
+	} else {
+		posts = nil
+	}
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err,
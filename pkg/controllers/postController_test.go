@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPinPost(t *testing.T) {
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Post is pinned under the limit",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOwnPost(mock)
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testViewerID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec("UPDATE posts").WithArgs(sqlmock.AnyArg(), testPostID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Post is pinned.",
+		},
+		{
+			name: "Fourth post can not be pinned",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOwnPost(mock)
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testViewerID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "You can pin up to 3 posts.",
+		},
+		{
+			name: "Only the author can pin the post",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/post/"+testPostID+"/pin", nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRankedFeedKeepsRankingTime(t *testing.T) {
+	rankedAt := time.Now().Add(-time.Hour).Unix()
+	emptyPosts := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"})
+	}
+	expectRankedFeed := func(mock sqlmock.Sqlmock, now interface{}) {
+		mock.ExpectQuery("FROM timelines").WillReturnRows(emptyPosts())
+		mock.ExpectQuery("FROM posts INNER JOIN celebrities").WillReturnRows(emptyPosts())
+		mock.ExpectQuery("FROM posts").WithArgs(testViewerID, sqlmock.AnyArg(), now).WillReturnRows(emptyPosts())
+		for i := 0; i < 3; i++ {
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "count"}))
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantHeader bool
+	}{
+		{
+			name:       "First page returns the ranking time",
+			query:      "",
+			expect:     func(mock sqlmock.Sqlmock) { expectRankedFeed(mock, sqlmock.AnyArg()) },
+			wantStatus: http.StatusOK,
+			wantHeader: true,
+		},
+		{
+			//the posts created after the first page are left out
+			name:       "Next page is ranked with the time of the first page",
+			query:      "?ranked_at=" + strconv.FormatInt(rankedAt, 10),
+			expect:     func(mock sqlmock.Sqlmock) { expectRankedFeed(mock, time.Unix(rankedAt, 0)) },
+			wantStatus: http.StatusOK,
+			wantHeader: true,
+		},
+		{
+			name:       "Ranking time in the future is rejected",
+			query:      "?ranked_at=" + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10),
+			expect:     func(mock sqlmock.Sqlmock) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/home"+tt.query, nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get(rankedAtHeader); (got != "") != tt.wantHeader {
+				t.Errorf("%s header = %q, want it set: %v", rankedAtHeader, got, tt.wantHeader)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func ShowPrivacySettings(c *gin.Context) {
+
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the privacy settings of the logged in user
+	settings, err := models.GetPrivacySettings(initializers.DB, loggedInUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Could not find the privacy settings.",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, settings)
+}
+
+func UpdatePrivacySettings(c *gin.Context) {
+
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//Get the data off req body, the fields that are left out keep their saved values
+	var body struct {
+		FriendListAudience  string `json:"friend_list_audience"`
+		EmailAudience       string `json:"email_audience"`
+		DescriptionAudience string `json:"description_audience"`
+		PhotoAudience       string `json:"photo_audience"`
+		FriendRequests      string `json:"friend_requests"`
+		Searchable          *bool  `json:"searchable"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//get the saved settings of the logged in user
+	settings, err := models.GetPrivacySettings(initializers.DB, loggedInUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Could not find the privacy settings.",
+		})
+		return
+	}
+
+	//merge the sent values into the saved settings
+	for _, field := range []struct {
+		value string
+		saved *string
+	}{
+		{body.FriendListAudience, &settings.FriendListAudience},
+		{body.EmailAudience, &settings.EmailAudience},
+		{body.DescriptionAudience, &settings.DescriptionAudience},
+		{body.PhotoAudience, &settings.PhotoAudience},
+		{body.FriendRequests, &settings.FriendRequests},
+	} {
+		if field.value != "" {
+			*field.saved = field.value
+		}
+	}
+	if body.Searchable != nil {
+		settings.Searchable = *body.Searchable
+	}
+
+	//check the values of the settings
+	if !models.ValidAudience(settings.FriendListAudience) ||
+		!models.ValidAudience(settings.EmailAudience) ||
+		!models.ValidAudience(settings.DescriptionAudience) ||
+		!models.ValidAudience(settings.PhotoAudience) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Audience must be one of: everyone, friends, only_me.",
+		})
+		return
+	}
+	if !models.ValidFriendRequestsSetting(settings.FriendRequests) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Friend requests must be one of: everyone, friends_of_friends.",
+		})
+		return
+	}
+
+	//save the settings
+	settings.UserID = loggedInUserID
+	settings, err = models.SavePrivacySettings(initializers.DB, settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to update the privacy settings.",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/websocketrooms"
+	"github.com/gin-gonic/gin"
+)
+
+// toggleReaction works as a toggle, the same reaction again removes it and a different one replaces it.
+// It returns whether the user has the reaction on the target afterwards.
+func toggleReaction(userID string, targetType string, targetID string, reactionType string) (bool, error) {
+	reaction, err := models.GetReaction(initializers.DB, targetType, targetID, userID)
+	if err != nil {
+		return false, err
+	}
+	if reaction.Type == reactionType {
+		return false, models.RemoveReaction(initializers.DB, userID, targetType, targetID)
+	}
+	return true, models.React(initializers.DB, userID, targetType, targetID, reactionType)
+}
+
+// readReactionType reads the reaction type off req body
+func readReactionType(c *gin.Context) (string, bool) {
+	var body struct {
+		Type string `json:"type"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return "", false
+	}
+	if !models.ValidReactionType(body.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reaction must be one of: like, love, laugh, wow, sad, angry.",
+		})
+		return "", false
+	}
+	return body.Type, true
+}
+
+// getCommentForInteraction gets the comment and its post, and checks whether the user can react on the comment
+func getCommentForInteraction(c *gin.Context, userID string) (models.Comment, models.Post, bool) {
+	comment, err := models.GetCommentByID(initializers.DB, c.Param("id"))
+	if err != nil || comment.Hidden || comment.Deleted || !comment.Approved {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Comment does not exist",
+		})
+		return comment, models.Post{}, false
+	}
+	post, err := models.GetPostByID(initializers.DB, comment.PostID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return comment, post, false
+	}
+
+	//the user must be able to like the post, and the comment author must not be blocked in either direction
+	blocked, err := models.CheckBlockStatus(initializers.DB, userID, comment.UserID)
+	if !post.IsLive() || !models.CanInteract(initializers.DB, userID, post.UserID, models.ActionLike) || err != nil || (blocked && userID != comment.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return comment, post, false
+	}
+	return comment, post, true
+}
+
+func ReactToPost(c *gin.Context) {
+
+	//get post id from params
+	postID := c.Param("id")
+
+	//check whether this post exists
+	post, err := models.GetPostByID(initializers.DB, postID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//get user id from logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//check whether the logged in user can react on the post
+	if !post.IsLive() || !models.CanInteract(initializers.DB, loggedInUserID, post.UserID, models.ActionLike) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//Get the data off req body
+	reactionType, ok := readReactionType(c)
+	if !ok {
+		return
+	}
+
+	//save or remove the reaction
+	reacted, err := toggleReaction(loggedInUserID, models.ReactionTargetPost, post.ID, reactionType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to react on the post",
+		})
+		return
+	}
+	websocketrooms.Hub.PostCountsChanged(initializers.DB, post.ID)
+	if !reacted {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Reaction is removed.",
+		})
+		return
+	}
+	models.SaveNotification(initializers.DB, post.UserID, loggedInUserID, "reacted to your post", "/post/"+postID)
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Reaction is saved.",
+		"reaction": reactionType,
+	})
+}
+
+func ShowPostReactions(c *gin.Context) {
+
+	//get post id from params
+	postID := c.Param("id")
+
+	//check whether this post exists
+	post, err := models.GetPostByID(initializers.DB, postID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Post does not exist",
+		})
+		return
+	}
+
+	//get user id from logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//check whether the logged in user can see the post
+	if !models.CanViewPost(initializers.DB, loggedInUserID, post) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "This account is private.",
+		})
+		return
+	}
+
+	//the type is optional, all reactions are listed without it
+	reactionType := c.Query("type")
+	if reactionType != "" && !models.ValidReactionType(reactionType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reaction must be one of: like, love, laugh, wow, sad, angry.",
+		})
+		return
+	}
+
+	//get one page of the users that reacted
+	page, limit := getPagination(c)
+	users, err := models.GetReactionUsers(initializers.DB, models.ReactionTargetPost, post.ID, reactionType, loggedInUserID, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the reactions",
+		})
+		return
+	}
+
+	//Respond
+	if len(users) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No reactions yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+func ReactToComment(c *gin.Context) {
+
+	//get user id from logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//check whether the logged in user can react on the comment
+	comment, _, ok := getCommentForInteraction(c, loggedInUserID)
+	if !ok {
+		return
+	}
+
+	//Get the data off req body
+	reactionType, ok := readReactionType(c)
+	if !ok {
+		return
+	}
+
+	//save or remove the reaction
+	reacted, err := toggleReaction(loggedInUserID, models.ReactionTargetComment, comment.ID, reactionType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to react on the comment",
+		})
+		return
+	}
+	if !reacted {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Reaction is removed.",
+		})
+		return
+	}
+	models.SaveNotification(initializers.DB, comment.UserID, loggedInUserID, "reacted to your comment", "/post/"+comment.PostID)
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Reaction is saved.",
+		"reaction": reactionType,
+	})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+func expectOwnPost(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM posts").WithArgs(testPostID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+			AddRow(testPostID, "", "Test post", testViewerID, "", false, "published", nil, nil, nil, time.Now(), time.Now()))
+}
+
+func expectReaction(mock sqlmock.Sqlmock, reactionType string) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "target_type", "target_id", "type", "created_at", "updated_at"})
+	if reactionType != "" {
+		rows.AddRow("90969e94-124c-4f17-96f7-f5283df7d101", testViewerID, models.ReactionTargetPost, testPostID, reactionType, time.Now(), time.Now())
+	}
+	mock.ExpectQuery("FROM reactions").WithArgs(models.ReactionTargetPost, testPostID, testViewerID).WillReturnRows(rows)
+}
+
+func TestLikePostToggle(t *testing.T) {
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		wantBody string
+	}{
+		{
+			name: "First like is saved",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOwnPost(mock)
+				expectReaction(mock, "")
+				mock.ExpectExec("INSERT INTO reactions").
+					WithArgs(sqlmock.AnyArg(), testViewerID, models.ReactionTargetPost, testPostID, models.ReactionLike).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantBody: "Successfully liked post",
+		},
+		{
+			name: "Second like removes the like",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOwnPost(mock)
+				expectReaction(mock, models.ReactionLike)
+				mock.ExpectExec("DELETE FROM reactions").
+					WithArgs(testViewerID, models.ReactionTargetPost, testPostID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantBody: "Successfully disliked post",
+		},
+		{
+			name: "Like replaces another reaction",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOwnPost(mock)
+				expectReaction(mock, models.ReactionLove)
+				mock.ExpectExec("INSERT INTO reactions").
+					WithArgs(sqlmock.AnyArg(), testViewerID, models.ReactionTargetPost, testPostID, models.ReactionLike).
+					WillReturnResult(sqlmock.NewResult(1, 2))
+			},
+			wantBody: "Successfully liked post",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/post/"+testPostID+"/like", nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
+	"github.com/gin-gonic/gin"
+)
+
+// resolveReportTarget finds the owner and the url of the reported content, and checks whether the reporter could see it
+func resolveReportTarget(reporterID string, targetType string, targetID string) (string, string, bool) {
+	switch targetType {
+	case models.ReportTargetPost:
+		post, err := models.GetPostByID(initializers.DB, targetID)
+		if err != nil || !models.CanViewPost(initializers.DB, reporterID, post) {
+			return "", "", false
+		}
+		return post.UserID, "/post/" + post.ID, true
+
+	case models.ReportTargetComment:
+		comment, err := models.GetCommentByID(initializers.DB, targetID)
+		if err != nil {
+			return "", "", false
+		}
+		post, err := models.GetPostByID(initializers.DB, comment.PostID)
+		if err != nil || !models.CanViewPost(initializers.DB, reporterID, post) {
+			return "", "", false
+		}
+		return comment.UserID, "/post/" + post.ID, true
+
+	case models.ReportTargetUser:
+		user, err := models.GetUserByID(initializers.DB, targetID)
+		if err != nil {
+			return "", "", false
+		}
+		return user.ID, "/user/" + user.ID, true
+
+	case models.ReportTargetMessage:
+		message, err := models.GetMessageByID(initializers.DB, targetID)
+		if err != nil {
+			return "", "", false
+		}
+		user1, user2 := models.FindChatRoomUsers(initializers.DB, message.RoomID)
+		if reporterID != user1 && reporterID != user2 {
+			return "", "", false
+		}
+		return message.Sender, "/chat", true
+	}
+	return "", "", false
+}
+
+func ReportContent(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//Get the data off req body
+	var body struct {
+		TargetType string `json:"target_type"`
+		TargetID   string `json:"target_id"`
+		Reason     string `json:"reason"`
+		Note       string `json:"note"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//check the values sent by the user
+	if !models.ValidReportTarget(body.TargetType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Target type must be one of: post, comment, user, message.",
+		})
+		return
+	}
+	if !models.ValidReportReason(body.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown report reason.",
+		})
+		return
+	}
+
+	//find the reported content
+	ownerID, url, found := resolveReportTarget(loggedInUserID, body.TargetType, body.TargetID)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reported content is not found.",
+		})
+		return
+	}
+	if ownerID == loggedInUserID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You can not report your own content.",
+		})
+		return
+	}
+
+	//one user can have only one open report about the same content
+	if models.CheckOpenReport(initializers.DB, loggedInUserID, body.TargetType, body.TargetID) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "You already reported this content.",
+		})
+		return
+	}
+
+	//save the report
+	report := models.Report{
+		ReporterID:    loggedInUserID,
+		TargetType:    body.TargetType,
+		TargetID:      body.TargetID,
+		TargetOwnerID: ownerID,
+		URL:           url,
+		Reason:        body.Reason,
+		Note:          body.Note,
+	}
+	report, err := models.CreateReport(initializers.DB, report)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to report the content.",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, report)
+}
+
+func ShowReportQueue(c *gin.Context) {
+
+	//get the state from the query, open reports are shown by default
+	state := c.DefaultQuery("state", models.ReportStateOpen)
+	if state != models.ReportStateOpen && state != models.ReportStateActioned && state != models.ReportStateDismissed {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "State must be one of: open, actioned, dismissed.",
+		})
+		return
+	}
+
+	//get the reported content
+	queue, err := models.GetReportQueue(initializers.DB, state)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	//Respond
+	if len(queue) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No reports.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, queue)
+}
+
+func ShowTargetReports(c *gin.Context) {
+
+	//get all reports about the content
+	reports, err := models.GetReportsByTarget(initializers.DB, c.Param("type"), c.Param("targetID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	//Respond
+	if len(reports) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No reports.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// openTargetReport returns one of the open reports about the content, it carries the owner and the url of the content
+func openTargetReport(targetType string, targetID string) (models.Report, bool) {
+	reports, _ := models.GetReportsByTarget(initializers.DB, targetType, targetID)
+	for _, report := range reports {
+		if report.Report.State == models.ReportStateOpen {
+			return report.Report, true
+		}
+	}
+	return models.Report{}, false
+}
+
+// setContentHidden hides the reported content or makes it visible again
+func setContentHidden(targetType string, targetID string, hidden bool) error {
+	switch targetType {
+	case models.ReportTargetPost:
+		return models.SetPostHidden(initializers.DB, targetID, hidden)
+	case models.ReportTargetComment:
+		return models.SetCommentHidden(initializers.DB, targetID, hidden)
+	case models.ReportTargetMessage:
+		return models.SetMessageHidden(initializers.DB, targetID, hidden)
+	}
+	return nil
+}
+
+// approveHeldContent runs what was skipped while the content was held by the content filter, the mentions are saved
+// and the new content is announced as if it was posted just now, the approved edit notifies only the newly mentioned users
+func approveHeldContent(targetType string, targetID string) {
+	switch targetType {
+	case models.ReportTargetPost:
+		post, err := models.GetPostByID(initializers.DB, targetID)
+		if err != nil {
+			return
+		}
+		mentioned := saveApprovedMentions(models.MentionTargetPost, post.ID, post.Text)
+		if post.EditedAt != nil {
+			postEditedEffects(post, mentioned)
+			return
+		}
+		postPublishedEffects(post, mentioned)
+
+	case models.ReportTargetComment:
+		comment, err := models.GetCommentByID(initializers.DB, targetID)
+		if err != nil || comment.Deleted {
+			return
+		}
+		post, err := models.GetPostByID(initializers.DB, comment.PostID)
+		if err != nil {
+			return
+		}
+		mentioned := saveApprovedMentions(models.MentionTargetComment, comment.ID, comment.Text)
+		if comment.Edited {
+			notifyMentions(comment.UserID, mentioned, commentMentionViewer(comment, post), "mentioned you in a comment", "/post/"+post.ID)
+			return
+		}
+		var parent models.Comment
+		if comment.ParentID != "" {
+			parent, _ = models.GetCommentByID(initializers.DB, comment.ParentID)
+		}
+		commentAddedEffects(comment, parent, post, mentioned)
+
+	case models.ReportTargetMessage:
+		if message, err := models.GetMessageByID(initializers.DB, targetID); err == nil {
+			workers.LinkPreviews.Enqueue(message.Message)
+		}
+	}
+}
+
+// saveApprovedMentions saves the mentions of the approved text and returns the users that were not mentioned before
+func saveApprovedMentions(targetType string, targetID string, text string) []string {
+	previous := models.GetMentions(initializers.DB, targetType, targetID)
+	mentions, err := models.SaveMentions(initializers.DB, targetType, targetID, text)
+	if err != nil {
+		log.Println("failed to save the mentions of the approved content:", err)
+	}
+	return models.NewlyMentionedUsers(previous, mentions)
+}
+
+func ActionReports(c *gin.Context) {
+
+	//get the moderator
+	loggedInUser, _ := c.Get("user")
+	moderatorID := loggedInUser.(models.User).ID
+
+	targetType := c.Param("type")
+	targetID := c.Param("targetID")
+
+	//Get the data off req body
+	var body struct {
+		Action  string `json:"action"`
+		Days    int    `json:"days"`
+		Message string `json:"message"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//find the open report about the content
+	report, found := openTargetReport(targetType, targetID)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "There are no open reports about the content.",
+		})
+		return
+	}
+
+	//take the action
+	var err error
+	switch body.Action {
+	case "hide":
+		if targetType == models.ReportTargetUser {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Profiles can not be hidden, suspend the user instead.",
+			})
+			return
+		}
+		err = setContentHidden(targetType, targetID, true)
+	case "warn":
+		message := "sent you a warning about your content"
+		if body.Message != "" {
+			message = message + ": " + body.Message
+		}
+		models.SaveSystemNotification(initializers.DB, report.TargetOwnerID, moderatorID, message, report.URL)
+	case "suspend":
+		days := body.Days
+		if days <= 0 {
+			days = 7
+		}
+		_, err = models.SuspendUser(initializers.DB, report.TargetOwnerID, moderatorID, body.Message, time.Now().AddDate(0, 0, days))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Action must be one of: hide, warn, suspend.",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to take the action.",
+		})
+		return
+	}
+
+	//close the reports and notify the reporters
+	reporters, err := models.ResolveReports(initializers.DB, targetType, targetID, models.ReportStateActioned, moderatorID, body.Action)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to close the reports.",
+		})
+		return
+	}
+	for _, reporterID := range reporters {
+		models.SaveSystemNotification(initializers.DB, reporterID, moderatorID, "reviewed your report and took action", report.URL)
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Action is taken.",
+	})
+}
+
+func DismissReports(c *gin.Context) {
+
+	//get the moderator
+	loggedInUser, _ := c.Get("user")
+	moderatorID := loggedInUser.(models.User).ID
+
+	targetType := c.Param("type")
+	targetID := c.Param("targetID")
+
+	//find the open report about the content
+	report, found := openTargetReport(targetType, targetID)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "There are no open reports about the content.",
+		})
+		return
+	}
+
+	//the content held by the content filter is fine, so show it again
+	if models.IsHeldForReview(initializers.DB, targetType, targetID) {
+		if err := setContentHidden(targetType, targetID, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to restore the content.",
+			})
+			return
+		}
+		approveHeldContent(targetType, targetID)
+	}
+
+	//close the reports and notify the reporters
+	reporters, err := models.ResolveReports(initializers.DB, targetType, targetID, models.ReportStateDismissed, moderatorID, "dismiss")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to close the reports.",
+		})
+		return
+	}
+	for _, reporterID := range reporters {
+		models.SaveSystemNotification(initializers.DB, reporterID, moderatorID, "reviewed your report, no action was needed", report.URL)
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reports are dismissed.",
+	})
+}
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+const testReporterID = "90969e94-124c-4f17-96f7-f5283df7da0a"
+
+func expectOpenReport(mock sqlmock.Sqlmock, targetType string, targetID string, ownerID string) {
+	mock.ExpectQuery("FROM reports").WithArgs(targetType, targetID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "reporter_id", "target_type", "target_id", "target_owner_id", "url", "reason", "note",
+			"state", "moderator_id", "resolution", "created_at", "updated_at", "first_name", "last_name"}).
+			AddRow("report-id", testReporterID, targetType, targetID, ownerID, "/post/"+testPostID, "spam", "",
+				models.ReportStateOpen, "", "", time.Now(), time.Now(), "", ""))
+}
+
+func expectResolveReports(mock sqlmock.Sqlmock, targetType string, targetID string, state string, resolution string) {
+	mock.ExpectQuery("SELECT DISTINCT reporter_id").WithArgs(targetType, targetID, models.ReportStateOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"reporter_id"}).AddRow(testReporterID))
+	mock.ExpectExec("UPDATE reports").WithArgs(state, testViewerID, resolution, targetType, targetID, models.ReportStateOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestReportContent(t *testing.T) {
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Blocked user can not report the post",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPost(mock)
+				expectBlock(mock)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Reported content is not found.",
+		},
+		{
+			name: "Hidden post can not be reported",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM posts").WithArgs(testPostID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+						AddRow(testPostID, "", "Test post", testOwnerID, "", true, "published", nil, nil, nil, time.Now(), time.Now()))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Reported content is not found.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			body := `{"target_type":"post","target_id":"` + testPostID + `","reason":"spam"}`
+			req, _ := http.NewRequest(http.MethodPost, "/report", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestModerateReports(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Content without open reports can not be actioned",
+			path: "/moderation/reports/post/" + testPostID + "/action",
+			body: `{"action":"hide"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM reports").WithArgs(models.ReportTargetPost, testPostID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "There are no open reports about the content.",
+		},
+		{
+			name: "Profile can not be hidden",
+			path: "/moderation/reports/user/" + testOwnerID + "/action",
+			body: `{"action":"hide"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOpenReport(mock, models.ReportTargetUser, testOwnerID, testOwnerID)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Profiles can not be hidden, suspend the user instead.",
+		},
+		{
+			name: "Post is hidden and the reporter is notified",
+			path: "/moderation/reports/post/" + testPostID + "/action",
+			body: `{"action":"hide"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOpenReport(mock, models.ReportTargetPost, testPostID, testOwnerID)
+				mock.ExpectExec("UPDATE posts").WithArgs(true, testPostID).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectPost(mock)
+				expectResolveReports(mock, models.ReportTargetPost, testPostID, models.ReportStateActioned, "hide")
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testReporterID, testViewerID, "/post/"+testPostID, "reviewed your report and took action", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Action is taken.",
+		},
+		{
+			//the warning is saved without checking the blocks and the mutes of the owner
+			name: "Owner is warned even if the moderator is muted",
+			path: "/moderation/reports/post/" + testPostID + "/action",
+			body: `{"action":"warn","message":"spam"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOpenReport(mock, models.ReportTargetPost, testPostID, testOwnerID)
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testOwnerID, testViewerID, "/post/"+testPostID, "sent you a warning about your content: spam", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectResolveReports(mock, models.ReportTargetPost, testPostID, models.ReportStateActioned, "warn")
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testReporterID, testViewerID, "/post/"+testPostID, "reviewed your report and took action", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Action is taken.",
+		},
+		{
+			name: "Unknown action is rejected",
+			path: "/moderation/reports/post/" + testPostID + "/action",
+			body: `{"action":"delete"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOpenReport(mock, models.ReportTargetPost, testPostID, testOwnerID)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Action must be one of: hide, warn, suspend.",
+		},
+		{
+			name: "Reports are dismissed and the reporter is notified",
+			path: "/moderation/reports/post/" + testPostID + "/dismiss",
+			body: `{}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOpenReport(mock, models.ReportTargetPost, testPostID, testOwnerID)
+				mock.ExpectQuery("SELECT COUNT").WithArgs(models.ReportTargetPost, testPostID, models.ReportReasonContentFilter, models.ReportStateOpen).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				expectResolveReports(mock, models.ReportTargetPost, testPostID, models.ReportStateDismissed, "dismiss")
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testReporterID, testViewerID, "/post/"+testPostID, "reviewed your report, no action was needed", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Reports are dismissed.",
+		},
+		{
+			name: "Approved comment notifies the mentioned user",
+			path: "/moderation/reports/comment/" + testCommentID + "/dismiss",
+			body: `{}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectOpenReport(mock, models.ReportTargetComment, testCommentID, testOwnerID)
+				mock.ExpectQuery("SELECT COUNT").WithArgs(models.ReportTargetComment, testCommentID, models.ReportReasonContentFilter, models.ReportStateOpen).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectExec("UPDATE comments").WithArgs(false, testCommentID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				//the mentions held with the comment are saved on the approval
+				expectComment(mock, testOwnerID, "Thanks @reporter", false)
+				expectPost(mock)
+				mock.ExpectQuery("FROM mentions").
+					WillReturnRows(sqlmock.NewRows([]string{"target_id", "user_id", "username", "offset", "length"}))
+				mock.ExpectQuery("FROM users").WithArgs("reporter").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(testReporterID, "reporter"))
+				mock.ExpectExec("DELETE FROM mentions").WithArgs(models.MentionTargetComment, testCommentID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO mentions").WillReturnResult(sqlmock.NewResult(1, 1))
+
+				//the mentioned user can see the post and did not block or mute the author
+				mock.ExpectQuery("FROM blocks").WithArgs(testReporterID, testOwnerID, testOwnerID, testReporterID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("SELECT profile_type").WithArgs(testOwnerID).
+					WillReturnRows(sqlmock.NewRows([]string{"profile_type"}).AddRow("public"))
+				mock.ExpectQuery("FROM blocks").WithArgs(testOwnerID, testReporterID, testReporterID, testOwnerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("FROM mutes").WithArgs(testReporterID, testOwnerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_mute_id", "user_muted_id", "created_at", "updated_at"}))
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testReporterID, testOwnerID, "/post/"+testPostID, "mentioned you in a comment", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				expectResolveReports(mock, models.ReportTargetComment, testCommentID, models.ReportStateDismissed, "dismiss")
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testReporterID, testViewerID, "/post/"+testPostID, "reviewed your report, no action was needed", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Reports are dismissed.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newTestRouter(t)
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
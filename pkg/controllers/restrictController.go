@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func RestrictUser(c *gin.Context) {
+
+	//get the searched user id from params
+	restrictUser := c.Param("id")
+
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	if restrictUser == loggedInUserID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User can not restrict himself.",
+		})
+		return
+	}
+
+	//check whether the user is already restricted
+	if models.CheckRestrictStatus(initializers.DB, loggedInUserID, restrictUser) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "User is already restricted.",
+		})
+		return
+	}
+
+	//restrict the user (new comments of the user on logged in user's posts wait for the approval)
+	ID := uuid.New().String()
+	if err := models.RestrictUser(initializers.DB, ID, loggedInUserID, restrictUser); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User could not be restricted.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User is successfully restricted.",
+	})
+}
+
+func UnrestrictUser(c *gin.Context) {
+
+	//get the searched user id from params
+	userID := c.Param("id")
+
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the restriction, and then remove it
+	restrict, err := models.GetRestrict(initializers.DB, loggedInUserID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err,
+		})
+		return
+	}
+	if err := models.Unrestrict(initializers.DB, restrict.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User is unrestricted.",
+	})
+}
+
+func ShowRestrictedUsers(c *gin.Context) {
+	//get the logged in user id
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	restrictedUsers, err := models.GetRestrictedUsers(initializers.DB, loggedInUserID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	//send json with all restricted users (sorted) or display a message
+	if len(restrictedUsers) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No restricted users yet.",
+		})
+		return
+	} else {
+		sort.Slice(restrictedUsers, func(p, q int) bool {
+			return restrictedUsers[p].UserRestrictedFirstName < restrictedUsers[q].UserRestrictedFirstName
+		})
+
+		c.JSON(http.StatusOK, restrictedUsers)
+		return
+	}
+}
@@ -17,6 +17,14 @@ func OpenChatRoom(c *gin.Context) {
 	//get the user from the params
 	userID := c.Param("userID")
 
+	//check whether the logged in user can chat with the user
+	if !models.CanInteract(initializers.DB, loggedInUserID, userID, models.ActionMessage) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
 	//get the room
 	room, err := models.FindChatRoom(initializers.DB, loggedInUserID, userID)
 	if err != nil {
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// autocompleteLimit is the number of the users and the hashtags suggested while typing
+const autocompleteLimit = 5
+
+func Search(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the search text and the type of the results, all types are searched by default
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Search text is required.",
+		})
+		return
+	}
+	searchType := c.Query("type")
+	if searchType != "" && searchType != "users" && searchType != "posts" && searchType != "hashtags" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Type must be one of: users, posts, hashtags.",
+		})
+		return
+	}
+	page, limit := getPagination(c)
+	offset := (page - 1) * limit
+
+	//search every requested type, the blocked users and the content the logged in user can not see are left out
+	results := gin.H{}
+	found := false
+	if searchType == "" || searchType == "users" {
+		users, err := models.SearchUsers(initializers.DB, loggedInUserID, text, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to search the users",
+			})
+			return
+		}
+		usersInfo := []models.UserAPI{}
+		for _, user := range users {
+			usersInfo = append(usersInfo, models.UserInfo(user, loggedInUserID))
+		}
+		results["users"] = usersInfo
+		found = found || len(usersInfo) > 0
+	}
+	if searchType == "" || searchType == "posts" {
+		posts, err := models.SearchPosts(initializers.DB, loggedInUserID, text, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to search the posts",
+			})
+			return
+		}
+		postsInfo := []models.PostAPI{}
+		for _, post := range posts {
+			postsInfo = append(postsInfo, models.PostInfo(post, loggedInUserID))
+		}
+		results["posts"] = postsInfo
+		found = found || len(postsInfo) > 0
+	}
+	if searchType == "" || searchType == "hashtags" {
+		hashtags, err := models.SearchHashtags(initializers.DB, loggedInUserID, text, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to search the hashtags",
+			})
+			return
+		}
+		if hashtags == nil {
+			hashtags = []models.HashtagAPI{}
+		}
+		results["hashtags"] = hashtags
+		found = found || len(hashtags) > 0
+	}
+
+	//Respond
+	if !found {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No results.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func SearchAutocomplete(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//the suggestions start from the first letters
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"users":    []models.Author{},
+			"hashtags": []models.HashtagAPI{},
+		})
+		return
+	}
+
+	//suggest a few users and hashtags
+	users, err := models.SearchUsers(initializers.DB, loggedInUserID, text, autocompleteLimit, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to search the users",
+		})
+		return
+	}
+	hashtags, err := models.SearchHashtags(initializers.DB, loggedInUserID, text, autocompleteLimit, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to search the hashtags",
+		})
+		return
+	}
+
+	//only the names and the photos are sent, the photo hidden by the privacy settings is left out
+	type suggestedUser struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		models.Author
+	}
+	suggestedUsers := []suggestedUser{}
+	for _, user := range users {
+		user = models.ApplyPrivacySettings(initializers.DB, user, loggedInUserID)
+		suggestedUsers = append(suggestedUsers, suggestedUser{
+			ID:       user.ID,
+			Username: user.Username,
+			Author:   models.Author{FirstName: user.FirstName, LastName: user.LastName, UserPhotoURL: user.UserPhotoURL},
+		})
+	}
+	if hashtags == nil {
+		hashtags = []models.HashtagAPI{}
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"users":    suggestedUsers,
+		"hashtags": hashtags,
+	})
+}
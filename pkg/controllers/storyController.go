@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/contentfilter"
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+func CreateStory(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//Get the data off req body
+	var body struct {
+		Photo string `json:"photo"`
+		Text  string `json:"text"`
+	}
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+	if body.Photo == "" && body.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Story must have a photo or a text.",
+		})
+		return
+	}
+
+	//check the text with the content filter, the story expires before a moderator could review it, so held stories are not shared
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindPost, body.Text)
+	if !ok {
+		return
+	}
+	if result.Verdict == contentfilter.Hold {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Story can not be shared: " + result.Reason,
+		})
+		return
+	}
+
+	//save the story
+	story, err := models.CreateStory(initializers.DB, models.Story{
+		UserID: loggedInUserID,
+		Photo:  body.Photo,
+		Text:   body.Text,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to share the story",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, story)
+}
+
+func ShowStoryTray(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//the tray has the stories of the logged in user and the friends that are not muted and still allow to see their posts
+	authors := []string{loggedInUserID}
+	mutedUsers, err := models.GetMutedUsersID(initializers.DB, loggedInUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the muted users",
+		})
+		return
+	}
+	for _, friendID := range models.GetFriendsIDs(initializers.DB, loggedInUserID) {
+		if !slices.Contains(mutedUsers, friendID) && models.CanInteract(initializers.DB, loggedInUserID, friendID, models.ActionViewPost) {
+			authors = append(authors, friendID)
+		}
+	}
+
+	//get the active stories
+	tray, err := models.GetActiveStoriesByUsers(initializers.DB, authors, loggedInUserID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the stories",
+		})
+		return
+	}
+
+	//Respond
+	if len(tray) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No stories yet.",
+		})
+		return
+	}
+	models.SortStoryTray(tray)
+	c.JSON(http.StatusOK, tray)
+}
+
+// getVisibleStory finds the story the logged in user can see, otherwise it responds with the error and returns false
+func getVisibleStory(c *gin.Context, loggedInUserID string) (models.Story, bool) {
+	story, err := models.GetStoryByID(initializers.DB, c.Param("id"))
+	if err != nil || !models.CanViewStory(initializers.DB, loggedInUserID, story, time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Story does not exist",
+		})
+		return story, false
+	}
+	return story, true
+}
+
+func DisplayStory(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the story
+	story, ok := getVisibleStory(c, loggedInUserID)
+	if !ok {
+		return
+	}
+
+	//the author does not count as a viewer
+	if story.UserID != loggedInUserID {
+		if err := models.MarkStorySeen(initializers.DB, story.ID, loggedInUserID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to mark the story as seen",
+			})
+			return
+		}
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, story)
+}
+
+func ShowStoryViewers(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the story
+	story, ok := getVisibleStory(c, loggedInUserID)
+	if !ok {
+		return
+	}
+
+	//only the author can see who has seen the story
+	if story.UserID != loggedInUserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//get the viewers
+	viewers, err := models.GetStoryViewers(initializers.DB, story.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get the viewers",
+		})
+		return
+	}
+
+	//Respond
+	if len(viewers) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No views yet.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, viewers)
+}
+
+func ReplyToStory(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the story
+	story, ok := getVisibleStory(c, loggedInUserID)
+	if !ok {
+		return
+	}
+	if story.UserID == loggedInUserID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You can not reply to your own story.",
+		})
+		return
+	}
+
+	//check whether the logged in user can chat with the author
+	if !models.CanInteract(initializers.DB, loggedInUserID, story.UserID, models.ActionMessage) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//Get the data off req body
+	var body struct {
+		Message string `json:"message"`
+	}
+	if c.Bind(&body) != nil || body.Message == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read body",
+		})
+		return
+	}
+
+	//check the message with the content filter
+	result, ok := checkContent(c, loggedInUserID, contentfilter.KindMessage, body.Message)
+	if !ok {
+		return
+	}
+
+	//the reply goes into the chat room of the two users
+	room, err := models.FindChatRoom(initializers.DB, loggedInUserID, story.UserID)
+	if err != nil {
+		room, err = models.CreateRoom(initializers.DB, loggedInUserID, story.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to find the chat room.",
+			})
+			return
+		}
+	}
+
+	//save the message
+	message := models.Message{
+		ID:        uuid.New().String(),
+		RoomID:    room.ID,
+		Sender:    loggedInUserID,
+		Message:   "Replied to your story: " + body.Message,
+		CreatedAt: time.Now(),
+	}
+	models.SendMessage(initializers.DB, message.ID, message.RoomID, message.Sender, message.Message, message.CreatedAt)
+
+	//the message held by the filter stays hidden until a moderator reviews it
+	if result.Verdict == contentfilter.Hold {
+		models.SetMessageHidden(initializers.DB, message.ID, true)
+		models.HoldForReview(initializers.DB, models.ReportTargetMessage, message.ID, loggedInUserID, "/chat", result.Reason)
+		message.Hidden = true
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Reply is held for review.",
+			"reply":   message,
+		})
+		return
+	}
+	workers.LinkPreviews.Enqueue(message.Message)
+	models.SaveNotification(initializers.DB, story.UserID, loggedInUserID, "replied to your story", "/chat")
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"room_id": room.ID,
+		"reply":   message,
+	})
+}
+
+func DeleteStory(c *gin.Context) {
+
+	//get the logged in user
+	loggedInUser, _ := c.Get("user")
+	loggedInUserID := loggedInUser.(models.User).ID
+
+	//get the story, only the author can delete it
+	story, err := models.GetStoryByID(initializers.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Story does not exist",
+		})
+		return
+	}
+	if story.UserID != loggedInUserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized.",
+		})
+		return
+	}
+
+	//delete the story
+	if err := models.DeleteStory(initializers.DB, story.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Story could not be deleted.",
+		})
+		return
+	}
+
+	//Respond
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Story deleted",
+	})
+}
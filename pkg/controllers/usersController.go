@@ -131,7 +131,7 @@ func Login(c *gin.Context) {
 func DisplayLoggedInUser(c *gin.Context) {
 
 	loggedInUser, _ := c.Get("user")
-	userInfo := models.UserInfo(loggedInUser.(models.User))
+	userInfo := models.UserInfo(loggedInUser.(models.User), loggedInUser.(models.User).ID)
 
 	//Respond
 	c.JSON(http.StatusOK, userInfo)
@@ -219,8 +219,7 @@ func SearchUser(c *gin.Context) {
 	loggedInUserID := loggedInUser.(models.User).ID
 
 	//get search param
-	searchKeyword := c.Query("search")
-	searchKeyword = "%" + searchKeyword + "%"
+	searchKeyword := strings.TrimSpace(c.Query("search"))
 
 	var users []models.User
 	var err error
@@ -236,8 +235,9 @@ func SearchUser(c *gin.Context) {
 			return
 		}
 	} else {
-		//else, return users where username contains the search param
-		users, err = models.GetUsersByName(initializers.DB, searchKeyword)
+		//else, return one page of the users found by the username, the name or the description, the best matches first
+		page, limit := getPagination(c)
+		users, err = models.SearchUsers(initializers.DB, loggedInUserID, searchKeyword, limit, (page-1)*limit)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": err.Error(),
@@ -252,24 +252,17 @@ func SearchUser(c *gin.Context) {
 		user := users[i]
 		user.Password = ""
 
-		blocked, err := models.CheckBlockStatus(initializers.DB, loggedInUserID, user.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-
-		if blocked {
+		//skip the users that blocked or are blocked by the logged in user
+		if !models.CanInteract(initializers.DB, loggedInUserID, user.ID, models.ActionViewProfile) {
 			continue
 		}
 
-		userInfo := models.UserInfo(users[i])
+		userInfo := models.UserInfo(users[i], loggedInUserID)
 		usersInfo = append(usersInfo, userInfo)
 	}
 
 	//check if there is any user that satisfies the search param
-	if len(users) > 0 {
+	if len(usersInfo) > 0 {
 		c.JSON(http.StatusOK, usersInfo)
 	} else {
 		c.JSON(http.StatusOK, gin.H{
@@ -287,15 +280,8 @@ func DisplayProfile(c *gin.Context) {
 	loggedInUser, _ := c.Get("user")
 	loggedInUserID := loggedInUser.(models.User).ID
 
-	blocked, err := models.CheckBlockStatus(initializers.DB, loggedInUserID, userID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
-
-	if blocked {
+	//check whether the logged in user can see the profile
+	if !models.CanInteract(initializers.DB, loggedInUserID, userID, models.ActionViewProfile) {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Profile could not be displayed.",
 		})
@@ -312,7 +298,7 @@ func DisplayProfile(c *gin.Context) {
 		return
 	}
 
-	userInfo := models.UserInfo(user)
+	userInfo := models.UserInfo(user, loggedInUserID)
 
 	//if there is a user with that id, show the user, otherwise show the message
 	if user.ID != "" {
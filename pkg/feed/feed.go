@@ -0,0 +1,107 @@
+// Package feed ranks the posts of the home feed.
+package feed
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Candidate is the post that can be shown in the feed, with the signals used for ranking it
+type Candidate struct {
+	PostID    string
+	AuthorID  string
+	CreatedAt time.Time
+	HasPhoto  bool
+	IsRepost  bool
+	// Affinity is how much the viewer interacted with the author lately (reactions, comments and messages)
+	Affinity float64
+	// Engagement is the number of the reactions and the comments the post got lately
+	Engagement int
+	Score      float64
+}
+
+// Weights of the signals, the zero weight turns the signal off
+type Weights struct {
+	Recency    float64
+	Affinity   float64
+	Engagement float64
+	// the hours after which the recency of the post halves
+	HalfLifeHours float64
+	// the multipliers of the content types, the text post has 1
+	Photo  float64
+	Repost float64
+	// the score of every next post of the same author is multiplied by it, so that no author floods the feed
+	AuthorDecay float64
+}
+
+var DefaultWeights = Weights{
+	Recency:       1,
+	Affinity:      0.6,
+	Engagement:    0.4,
+	HalfLifeHours: 12,
+	Photo:         1.2,
+	Repost:        0.8,
+	AuthorDecay:   0.6,
+}
+
+// Score combines the signals of the post, the new posts, the posts of the close friends and the posts that get attention quickly score higher
+func Score(candidate Candidate, weights Weights, now time.Time) float64 {
+	ageHours := math.Max(now.Sub(candidate.CreatedAt).Hours(), 0)
+	recency := math.Pow(0.5, ageHours/weights.HalfLifeHours)
+	affinity := math.Log1p(candidate.Affinity)
+
+	//the velocity is the engagement per hour, the first hour counts as a whole hour
+	velocity := math.Log1p(float64(candidate.Engagement) / math.Max(ageHours, 1))
+
+	score := weights.Recency*recency + weights.Affinity*affinity + weights.Engagement*velocity
+	switch {
+	case candidate.IsRepost:
+		score *= weights.Repost
+	case candidate.HasPhoto:
+		score *= weights.Photo
+	}
+	return score
+}
+
+// Rank scores the candidates and orders them, the order is diversified so that the posts of one author are spread through the feed
+func Rank(candidates []Candidate, weights Weights, now time.Time) []Candidate {
+	for i := range candidates {
+		candidates[i].Score = Score(candidates[i], weights, now)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+	})
+	return Diversify(candidates, weights.AuthorDecay)
+}
+
+// Diversify picks the posts one by one. Every post of the author that is already picked lowers the score of the next one,
+// and the same author is not picked twice in a row while there are posts of other authors left.
+func Diversify(ranked []Candidate, decay float64) []Candidate {
+	remaining := append([]Candidate(nil), ranked...)
+	picked := make(map[string]int)
+	diversified := make([]Candidate, 0, len(ranked))
+	lastAuthor := ""
+
+	for len(remaining) > 0 {
+		best := -1
+		bestScore := 0.0
+		for i, candidate := range remaining {
+			if candidate.AuthorID == lastAuthor && best != -1 {
+				continue
+			}
+			score := candidate.Score * math.Pow(decay, float64(picked[candidate.AuthorID]))
+			if best == -1 || score > bestScore || (remaining[best].AuthorID == lastAuthor && candidate.AuthorID != lastAuthor) {
+				best, bestScore = i, score
+			}
+		}
+		diversified = append(diversified, remaining[best])
+		picked[remaining[best].AuthorID]++
+		lastAuthor = remaining[best].AuthorID
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return diversified
+}
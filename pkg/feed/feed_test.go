@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+
+func candidateIDs(candidates []Candidate) []string {
+	var ids []string
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.PostID)
+	}
+	return ids
+}
+
+func TestScore(t *testing.T) {
+	fresh := Candidate{PostID: "fresh", CreatedAt: testNow.Add(-time.Hour)}
+	old := Candidate{PostID: "old", CreatedAt: testNow.Add(-48 * time.Hour)}
+	if Score(fresh, DefaultWeights, testNow) <= Score(old, DefaultWeights, testNow) {
+		t.Error("the newer post should score higher")
+	}
+
+	closeFriend := old
+	closeFriend.Affinity = 20
+	if Score(closeFriend, DefaultWeights, testNow) <= Score(old, DefaultWeights, testNow) {
+		t.Error("the post of the close friend should score higher")
+	}
+
+	popular := old
+	popular.Engagement = 200
+	if Score(popular, DefaultWeights, testNow) <= Score(old, DefaultWeights, testNow) {
+		t.Error("the post with the engagement should score higher")
+	}
+
+	photo, repost := fresh, fresh
+	photo.HasPhoto = true
+	repost.IsRepost = true
+	if !(Score(photo, DefaultWeights, testNow) > Score(fresh, DefaultWeights, testNow) && Score(fresh, DefaultWeights, testNow) > Score(repost, DefaultWeights, testNow)) {
+		t.Error("the photo should score higher and the repost lower than the text post")
+	}
+
+	future := Candidate{PostID: "future", CreatedAt: testNow.Add(time.Hour)}
+	if score := Score(future, DefaultWeights, testNow); score > DefaultWeights.Recency {
+		t.Errorf("the post from the future should not score more than the new post, got %v", score)
+	}
+}
+
+func TestRankDiversifiesAuthors(t *testing.T) {
+	var candidates []Candidate
+	for i, id := range []string{"a1", "a2", "a3", "a4"} {
+		candidates = append(candidates, Candidate{PostID: id, AuthorID: "a", Affinity: 50, CreatedAt: testNow.Add(-time.Duration(i) * time.Minute)})
+	}
+	candidates = append(candidates,
+		Candidate{PostID: "b1", AuthorID: "b", CreatedAt: testNow.Add(-2 * time.Hour)},
+		Candidate{PostID: "c1", AuthorID: "c", CreatedAt: testNow.Add(-3 * time.Hour)},
+	)
+
+	ranked := Rank(candidates, DefaultWeights, testNow)
+	expected := []string{"a1", "b1", "a2", "c1", "a3", "a4"}
+	if ids := candidateIDs(ranked); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestDiversifyKeepsOrderOfDifferentAuthors(t *testing.T) {
+	ranked := []Candidate{
+		{PostID: "p1", AuthorID: "a", Score: 3},
+		{PostID: "p2", AuthorID: "b", Score: 2},
+		{PostID: "p3", AuthorID: "c", Score: 1},
+	}
+	if ids := candidateIDs(Diversify(ranked, 0.5)); !reflect.DeepEqual(ids, []string{"p1", "p2", "p3"}) {
+		t.Errorf("the order should not change, got %v", ids)
+	}
+}
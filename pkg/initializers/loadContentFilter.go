@@ -0,0 +1,40 @@
+package initializers
+
+import (
+	"os"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/contentfilter"
+)
+
+// ContentFilter checks the posts, comments and chat messages before they are saved
+var ContentFilter contentfilter.ContentFilter = defaultContentFilter()
+
+func defaultContentFilter() *contentfilter.RuleFilter {
+	filter, _ := contentfilter.NewRuleFilter(contentfilter.Rules{
+		MaxLinks:            5,
+		RepeatLimit:         3,
+		RepeatWindowSeconds: 60,
+	})
+	return filter
+}
+
+func LoadContentFilter() {
+	path := os.Getenv("CONTENT_FILTER_RULES")
+	if path == "" {
+		return
+	}
+
+	rules, err := contentfilter.LoadRules(path)
+	if err != nil {
+		panic(err)
+	}
+	filter, err := contentfilter.NewRuleFilter(rules)
+	if err != nil {
+		panic(err)
+	}
+	ContentFilter = filter
+
+	//reload the rules when the file changes
+	go contentfilter.Watch(filter, path, 10*time.Second)
+}
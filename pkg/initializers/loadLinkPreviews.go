@@ -0,0 +1,29 @@
+package initializers
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/linkpreview"
+)
+
+// NewLinkPreviewFetcher creates the fetcher of the link previews. The fetched hosts can be limited
+// with the comma separated LINK_PREVIEW_ALLOWED_HOSTS and LINK_PREVIEW_DENIED_HOSTS.
+func NewLinkPreviewFetcher() linkpreview.Fetcher {
+	policy := linkpreview.Policy{
+		AllowedHosts: splitHosts(os.Getenv("LINK_PREVIEW_ALLOWED_HOSTS")),
+		DeniedHosts:  splitHosts(os.Getenv("LINK_PREVIEW_DENIED_HOSTS")),
+	}
+	return linkpreview.NewHTTPFetcher(policy, 5*time.Second, 512*1024)
+}
+
+func splitHosts(hosts string) []string {
+	var split []string
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			split = append(split, host)
+		}
+	}
+	return split
+}
@@ -0,0 +1,6 @@
+package initializers
+
+import "github.com/dika-bosnjak/social-media-app/pkg/search"
+
+// SearchIndex finds the users, the posts and the hashtags, it is filled from the database when the server starts
+var SearchIndex search.SearchIndex = search.NewMemoryIndex()
@@ -0,0 +1,205 @@
+// Package linkpreview fetches the OpenGraph and oEmbed previews of the links that users share.
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Preview is the title, the description and the image of the linked page
+type Preview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	SiteName    string `json:"site_name"`
+}
+
+// Fetcher gets the preview of the page, the tests replace it with a local stand-in
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (Preview, error)
+}
+
+var (
+	ErrNotAllowed = errors.New("link preview: host is not allowed")
+	ErrNoPreview  = errors.New("link preview: page has no preview")
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// ExtractURLs finds the http and https links in the text, every link is returned once
+func ExtractURLs(text string) []string {
+	var urls []string
+	for _, link := range linkRegexp.FindAllString(text, -1) {
+
+		//the punctuation at the end of the sentence is not a part of the link
+		link = strings.TrimRight(link, ".,!?;:)]}'")
+		if !slices.Contains(urls, link) {
+			urls = append(urls, link)
+		}
+	}
+	return urls
+}
+
+// Policy decides which hosts can be fetched. The denied hosts (and their subdomains) are never fetched,
+// and when the allowed hosts are set only they (and their subdomains) are fetched.
+type Policy struct {
+	AllowedHosts []string
+	DeniedHosts  []string
+	// the addresses in the private networks are refused unless this is set, it is meant only for the tests
+	AllowPrivateNetworks bool
+}
+
+func matchesHost(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsURL checks the scheme and the host of the link
+func (policy Policy) AllowsURL(link *url.URL) bool {
+	if link.Scheme != "http" && link.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(link.Hostname())
+	if host == "" || matchesHost(host, policy.DeniedHosts) {
+		return false
+	}
+	return len(policy.AllowedHosts) == 0 || matchesHost(host, policy.AllowedHosts)
+}
+
+// allowsIP refuses the addresses that point inside of our network, they are checked after the host is resolved
+func (policy Policy) allowsIP(ip net.IP) bool {
+	if policy.AllowPrivateNetworks {
+		return true
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// HTTPFetcher downloads the page and reads its OpenGraph tags, the oEmbed endpoint of the page is used when they are missing
+type HTTPFetcher struct {
+	Policy   Policy
+	MaxBytes int64
+	client   *http.Client
+}
+
+const maxRedirects = 3
+
+func NewHTTPFetcher(policy Policy, timeout time.Duration, maxBytes int64) *HTTPFetcher {
+	fetcher := &HTTPFetcher{Policy: policy, MaxBytes: maxBytes}
+
+	//the address is checked when the connection is made, so a host can not resolve to a public address first and a private one later
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !fetcher.Policy.allowsIP(ip) {
+				return ErrNotAllowed
+			}
+			return nil
+		},
+	}
+	fetcher.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("link preview: too many redirects")
+			}
+			if !fetcher.Policy.AllowsURL(req.URL) {
+				return ErrNotAllowed
+			}
+			return nil
+		},
+	}
+	return fetcher
+}
+
+func (fetcher *HTTPFetcher) get(ctx context.Context, link *url.URL, accept string) ([]byte, string, error) {
+	if !fetcher.Policy.AllowsURL(link) {
+		return nil, "", ErrNotAllowed
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "social-media-app link preview")
+
+	resp, err := fetcher.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("link preview: unexpected status %d", resp.StatusCode)
+	}
+
+	//only the beginning of the big pages is read, the meta tags are in the head anyway
+	body, err := io.ReadAll(io.LimitReader(resp.Body, fetcher.MaxBytes))
+	return body, resp.Header.Get("Content-Type"), err
+}
+
+func (fetcher *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	body, contentType, err := fetcher.get(ctx, link, "text/html")
+	if err != nil {
+		return Preview{}, err
+	}
+	if !strings.Contains(contentType, "html") {
+		return Preview{}, ErrNoPreview
+	}
+
+	//read the OpenGraph tags, and the oEmbed endpoint when the tags are missing
+	page := parsePage(body)
+	preview := page.preview
+	preview.URL = rawURL
+	if preview.Title == "" && page.oembedURL != "" {
+		if oembedURL, err := link.Parse(page.oembedURL); err == nil {
+			if data, _, err := fetcher.get(ctx, oembedURL, "application/json"); err == nil {
+				preview = mergeOEmbed(preview, data)
+			}
+		}
+	}
+	if preview.Title == "" {
+		preview.Title = page.title
+	}
+	if preview.Title == "" {
+		return Preview{}, ErrNoPreview
+	}
+
+	//the relative image is resolved against the page
+	if preview.ImageURL != "" {
+		if image, err := link.Parse(preview.ImageURL); err == nil {
+			preview.ImageURL = image.String()
+		}
+	}
+	return preview, nil
+}
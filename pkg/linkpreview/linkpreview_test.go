@@ -0,0 +1,118 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractURLs(t *testing.T) {
+	got := ExtractURLs("Look at https://example.com/a, and (http://example.org/b). Again https://example.com/a! www.example.net")
+	want := []string{"https://example.com/a", "http://example.org/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractURLs() = %v, want %v", got, want)
+	}
+}
+
+func TestPolicyAllowsURL(t *testing.T) {
+	policy := Policy{AllowedHosts: []string{"example.com"}, DeniedHosts: []string{"private.example.com"}}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://example.com/page", want: true},
+		{url: "https://www.example.com/page", want: true},
+		{url: "https://private.example.com/page", want: false},
+		{url: "https://example.org/page", want: false},
+		{url: "ftp://example.com/file", want: false},
+		{url: "https://notexample.com/page", want: false},
+	}
+	for _, tt := range tests {
+		link, _ := url.Parse(tt.url)
+		if got := policy.AllowsURL(link); got != tt.want {
+			t.Errorf("AllowsURL(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestFetchOpenGraph(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Page title</title>
+			<meta property="og:title" content="  Open  Graph title ">
+			<meta property="og:description" content="Description">
+			<meta property="og:image" content="/image.png">
+			<meta property="og:site_name" content="Example">
+			</head><body><meta property="og:title" content="Ignored"></body></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(Policy{AllowPrivateNetworks: true}, time.Second, 1024)
+	preview, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Preview{URL: server.URL + "/page", Title: "Open Graph title", Description: "Description", ImageURL: server.URL + "/image.png", SiteName: "Example"}
+	if preview != want {
+		t.Errorf("Fetch() = %+v, want %+v", preview, want)
+	}
+}
+
+func TestFetchOEmbed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/video", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/json+oembed" href="/oembed"></head></html>`))
+	})
+	mux.HandleFunc("/oembed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"title": "Video", "provider_name": "Videos", "thumbnail_url": "https://cdn.example.com/thumb.jpg"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(Policy{AllowPrivateNetworks: true}, time.Second, 1024)
+	preview, err := fetcher.Fetch(context.Background(), server.URL+"/video")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Video" || preview.SiteName != "Videos" || preview.ImageURL != "https://cdn.example.com/thumb.jpg" {
+		t.Errorf("Fetch() = %+v, want the oEmbed fields", preview)
+	}
+}
+
+func TestFetchRefusesPrivateNetworks(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(Policy{}, time.Second, 1024)
+	if _, err := fetcher.Fetch(context.Background(), server.URL); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrNotAllowed)
+	}
+	if requested {
+		t.Error("Fetch() reached the server in the private network")
+	}
+}
+
+func TestFetchReadsLimitedBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>` + strings.Repeat(" ", 2048) + `<title>Too late</title></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(Policy{AllowPrivateNetworks: true}, time.Second, 1024)
+	if _, err := fetcher.Fetch(context.Background(), server.URL); err != ErrNoPreview {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrNoPreview)
+	}
+}
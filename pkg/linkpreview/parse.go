@@ -0,0 +1,118 @@
+package linkpreview
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxFieldLength keeps the long descriptions from filling the cache
+const maxFieldLength = 300
+
+type parsedPage struct {
+	preview   Preview
+	title     string
+	oembedURL string
+}
+
+// parsePage reads the OpenGraph meta tags, the title and the oEmbed link from the head of the page
+func parsePage(body []byte) parsedPage {
+	var page parsedPage
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	inTitle := false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return page
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "meta":
+				readMeta(&page.preview, attributes(token))
+			case "link":
+				attrs := attributes(token)
+				if strings.EqualFold(attrs["rel"], "alternate") && strings.EqualFold(attrs["type"], "application/json+oembed") {
+					page.oembedURL = attrs["href"]
+				}
+			case "title":
+				inTitle = true
+			case "body":
+				return page
+			}
+		case html.TextToken:
+			if inTitle && page.title == "" {
+				page.title = clean(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			inTitle = false
+		}
+	}
+}
+
+func attributes(token html.Token) map[string]string {
+	attrs := make(map[string]string)
+	for _, attr := range token.Attr {
+		attrs[strings.ToLower(attr.Key)] = attr.Val
+	}
+	return attrs
+}
+
+func readMeta(preview *Preview, attrs map[string]string) {
+	property := attrs["property"]
+	if property == "" {
+		property = attrs["name"]
+	}
+	content := clean(attrs["content"])
+	switch strings.ToLower(property) {
+	case "og:title":
+		preview.Title = content
+	case "og:description":
+		preview.Description = content
+	case "description":
+		if preview.Description == "" {
+			preview.Description = content
+		}
+	case "og:image", "og:image:url":
+		if preview.ImageURL == "" {
+			preview.ImageURL = attrs["content"]
+		}
+	case "og:site_name":
+		preview.SiteName = content
+	}
+}
+
+// mergeOEmbed fills the missing fields from the oEmbed response
+func mergeOEmbed(preview Preview, data []byte) Preview {
+	var oembed struct {
+		Title        string `json:"title"`
+		AuthorName   string `json:"author_name"`
+		ProviderName string `json:"provider_name"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}
+	if json.Unmarshal(data, &oembed) != nil {
+		return preview
+	}
+	if preview.Title == "" {
+		preview.Title = clean(oembed.Title)
+	}
+	if preview.Description == "" {
+		preview.Description = clean(oembed.AuthorName)
+	}
+	if preview.SiteName == "" {
+		preview.SiteName = clean(oembed.ProviderName)
+	}
+	if preview.ImageURL == "" {
+		preview.ImageURL = oembed.ThumbnailURL
+	}
+	return preview
+}
+
+func clean(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxFieldLength {
+		text = string(runes[:maxFieldLength])
+	}
+	return text
+}
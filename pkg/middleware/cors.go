@@ -13,7 +13,7 @@ func EnableCORS(r *gin.Engine) {
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
 		AllowHeaders:     []string{"Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "Cache-Control"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "X-Ranked-At"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
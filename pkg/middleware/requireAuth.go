@@ -61,6 +61,14 @@ func RequireAuth(c *gin.Context) {
 			c.Abort()
 			return
 		}
+		//Suspended users can not use the app until the suspension ends
+		if models.IsSuspended(initializers.DB, user.ID) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Unauthorized. Account is suspended.",
+			})
+			c.Abort()
+			return
+		}
 		//Attach to req
 		user.Password = ""
 		c.Set("user", user)
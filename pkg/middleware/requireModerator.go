@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireModerator must be used after RequireAuth
+func RequireModerator(c *gin.Context) {
+
+	//get the logged in user attached by RequireAuth
+	loggedInUser, exists := c.Get("user")
+	if !exists || !models.IsModerator(initializers.DB, loggedInUser.(models.User).ID) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		c.Abort()
+		return
+	}
+
+	//Continue
+	c.Next()
+}
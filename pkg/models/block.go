@@ -100,11 +100,13 @@ func CheckBlockStatus(db *sql.DB, loggedInUserID string, userID string) (bool, e
 	if err != nil {
 		return false, err
 	}
+	defer rows.Close()
+
 	for rows.Next() {
 		return true, nil
 	}
 
-	return false, nil
+	return false, rows.Err()
 }
 
 func NumberOfBlockedUsers(db *sql.DB, loggedInUserID string) int {
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection is a named, private list of the saved posts
+type Collection struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(191);index"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Bookmark is the post saved by the user, the post without a collection is only in the list of all saved posts
+type Bookmark struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"user_id" gorm:"type:varchar(191);uniqueIndex:idx_bookmark_user_post"`
+	PostID       string    `json:"post_id" gorm:"type:varchar(191);uniqueIndex:idx_bookmark_user_post"`
+	CollectionID string    `json:"collection_id" gorm:"type:varchar(191);index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func CreateCollection(db *sql.DB, userID string, name string) (Collection, error) {
+
+	//create a new collection for the user
+	collection := Collection{ID: uuid.New().String(), UserID: userID, Name: name}
+	_, err := db.Exec(`INSERT INTO collections (id, user_id, name)
+						VALUES (?, ?, ?)`, collection.ID, collection.UserID, collection.Name)
+	return collection, err
+}
+
+func GetCollection(db *sql.DB, id string) (Collection, error) {
+
+	//get the collection by id from the database
+	var collection Collection
+	if err := db.QueryRow(`SELECT id, user_id, name, created_at, updated_at
+							FROM collections
+							WHERE id = ?`, id).
+		Scan(
+			&collection.ID,
+			&collection.UserID,
+			&collection.Name,
+			&collection.CreatedAt,
+			&collection.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return collection, errors.New("Collection not found in the database")
+		}
+		return collection, err
+	}
+	return collection, nil
+}
+
+func GetCollections(db *sql.DB, userID string) ([]Collection, error) {
+
+	//get all collections of the user
+	var collections []Collection
+	rows, err := db.Query(`SELECT id, user_id, name, created_at, updated_at
+							FROM collections
+							WHERE user_id = ?
+							ORDER BY name ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the collections slice
+	for rows.Next() {
+		var collection Collection
+		if err := rows.
+			Scan(&collection.ID,
+				&collection.UserID,
+				&collection.Name,
+				&collection.CreatedAt,
+				&collection.UpdatedAt); err != nil {
+			return collections, err
+		}
+		collections = append(collections, collection)
+	}
+	if err = rows.Err(); err != nil {
+		return collections, err
+	}
+	return collections, nil
+}
+
+func DeleteCollection(db *sql.DB, id string) error {
+
+	//the saved posts stay in the list of all saved posts
+	if _, err := db.Exec(`UPDATE bookmarks
+							SET collection_id = ''
+							WHERE collection_id = ?`, id); err != nil {
+		return err
+	}
+
+	//delete the collection in the database
+	_, err := db.Exec(`DELETE
+						FROM collections
+						WHERE id = ?`, id)
+	return err
+}
+
+func SavePost(db *sql.DB, userID string, postID string, collectionID string) error {
+
+	//save the post, the post that is already saved is moved into the collection
+	_, err := db.Exec(`INSERT INTO bookmarks (id, user_id, post_id, collection_id)
+						VALUES (?, ?, ?, ?)
+						ON DUPLICATE KEY UPDATE collection_id = VALUES(collection_id)`, uuid.New().String(), userID, postID, collectionID)
+	return err
+}
+
+func UnsavePost(db *sql.DB, userID string, postID string) error {
+
+	//remove the post from the saved posts
+	_, err := db.Exec(`DELETE
+						FROM bookmarks
+						WHERE user_id = ? AND post_id = ?`, userID, postID)
+	return err
+}
+
+func CheckIfSaved(db *sql.DB, postID string, userID string) bool {
+
+	//check whether the user saved the post
+	var count int
+	db.QueryRow(`SELECT COUNT(*)
+					FROM bookmarks
+					WHERE post_id = ? AND user_id = ?`, postID, userID).Scan(&count)
+	return count > 0
+}
+
+func GetSavedPosts(db *sql.DB, userID string, collectionID string, limit int, offset int) ([]Post, error) {
+
+	//get one page of the saved posts (of all saved posts when the collection is empty), the last saved first,
+	//the posts that became private or whose author blocked the user are left out
+	var posts []Post
+	args := append([]interface{}{userID, collectionID, collectionID}, postVisibleArgs(userID)...)
+	rows, err := db.Query(`SELECT `+postColumns+`
+							FROM bookmarks
+							INNER JOIN posts ON posts.id = bookmarks.post_id
+							INNER JOIN users ON users.id = posts.user_id
+							WHERE bookmarks.user_id = ? AND (? = '' OR bookmarks.collection_id = ?) AND `+postVisibleCondition+`
+							ORDER BY bookmarks.created_at DESC
+							LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the posts
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return posts, err
+		}
+		posts = append(posts, post)
+	}
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+	return posts, nil
+}
+
+func DeleteBookmarksOfPost(db *sql.DB, postID string) error {
+
+	//remove the deleted post from the saved posts of all users
+	_, err := db.Exec(`DELETE
+						FROM bookmarks
+						WHERE post_id = ?`, postID)
+	return err
+}
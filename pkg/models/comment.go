@@ -2,65 +2,300 @@ package models
 
 import (
 	"database/sql"
+	"errors"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// MaxCommentDepth is the deepest level of the replies, top level comments have depth 0
+const MaxCommentDepth = 3
+
+// orders of the comments
+const (
+	CommentSortOldest = "oldest"
+	CommentSortTop    = "top"
+)
+
+// DeletedCommentText replaces the text of the deleted comments that still have replies
+const DeletedCommentText = "[deleted]"
+
 type Comment struct {
 	ID        string    `json:"id" gorm:"primaryKey"`
 	UserID    string    `json:"user_id"  gorm:"type:varchar(191)"`
 	PostID    string    `json:"post_id"  gorm:"type:varchar(191)"`
+	ParentID  string    `json:"parent_id"  gorm:"type:varchar(191);index"`
+	Depth     int       `json:"depth" gorm:"default:0"`
 	Text      string    `json:"comment"`
+	Approved  bool      `json:"approved" gorm:"default:true"`
+	Hidden    bool      `json:"hidden" gorm:"default:false"`
+	Deleted   bool      `json:"deleted" gorm:"default:false"`
+	Edited    bool      `json:"edited" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type CommentAPI struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	UserPhotoURL string    `json:"user_photo_url"`
-	PostID       string    `json:"post_id"`
-	PostOwner    string    `json:"post_owner"`
-	Text         string    `json:"comment"`
-	EnableDelete bool      `json:"enable_delete"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-}
-
-func CreateComment(db *sql.DB, text string, userID string, postID string) (Comment, error) {
-
-	//create a new comment in the database
-	commentID := uuid.New().String()
-	comment := Comment{ID: commentID, UserID: userID, PostID: postID, Text: text}
-	_, err := db.Exec(`INSERT INTO comments (id, user_id, post_id, text) 
-						VALUES (?, ?, ?, ?)`, commentID, userID, postID, text)
+	ID            string         `json:"id"`
+	UserID        string         `json:"user_id"`
+	FirstName     string         `json:"first_name"`
+	LastName      string         `json:"last_name"`
+	UserPhotoURL  string         `json:"user_photo_url"`
+	PostID        string         `json:"post_id"`
+	PostOwner     string         `json:"post_owner"`
+	ParentID      string         `json:"parent_id"`
+	Depth         int            `json:"depth"`
+	Text          string         `json:"comment"`
+	Approved      bool           `json:"approved"`
+	Deleted       bool           `json:"deleted"`
+	Edited        bool           `json:"edited"`
+	Likes         int            `json:"likes"`
+	AlreadyLiked  bool           `json:"already_liked"`
+	Reactions     map[string]int `json:"reactions"`
+	MyReaction    string         `json:"my_reaction"`
+	Mentions      []Mention      `json:"mentions"`
+	ReplyCount    int            `json:"reply_count"`
+	Replies       []CommentAPI   `json:"replies,omitempty"`
+	EnableDelete  bool           `json:"enable_delete"`
+	EnableApprove bool           `json:"enable_approve"`
+	EnableReply   bool           `json:"enable_reply"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// commentColumns are the columns read by scanComment, in the same order
+const commentColumns = `comments.id, comments.user_id, comments.post_id, comments.parent_id, comments.depth, comments.text, comments.approved, comments.hidden, comments.deleted, comments.edited, comments.created_at, comments.updated_at`
+
+func scanComment(row rowScanner) (Comment, error) {
+	var comment Comment
+	err := row.Scan(
+		&comment.ID,
+		&comment.UserID,
+		&comment.PostID,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.Text,
+		&comment.Approved,
+		&comment.Hidden,
+		&comment.Deleted,
+		&comment.Edited,
+		&comment.CreatedAt,
+		&comment.UpdatedAt)
+	return comment, err
+}
+
+// commentAPIColumns are the columns read by scanCommentAPI, the query must join users and posts
+const commentAPIColumns = `comments.id, comments.user_id, users.first_name, users.last_name, users.user_photo_url, comments.post_id, posts.user_id, comments.parent_id, comments.depth,
+							comments.text, comments.approved, comments.deleted, comments.edited, comments.created_at, comments.updated_at`
+
+func scanCommentAPI(row rowScanner) (CommentAPI, error) {
+	var comment CommentAPI
+	err := row.Scan(
+		&comment.ID,
+		&comment.UserID,
+		&comment.FirstName,
+		&comment.LastName,
+		&comment.UserPhotoURL,
+		&comment.PostID,
+		&comment.PostOwner,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.Text,
+		&comment.Approved,
+		&comment.Deleted,
+		&comment.Edited,
+		&comment.CreatedAt,
+		&comment.UpdatedAt)
+	return comment, err
+}
+
+func CreateComment(db *sql.DB, comment Comment) (Comment, error) {
+
+	//create a new comment in the database (comments of the restricted users wait for the approval, the held comments are hidden)
+	comment.ID = uuid.New().String()
+	_, err := db.Exec(`INSERT INTO comments (id, user_id, post_id, parent_id, depth, text, approved, hidden)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, comment.ID, comment.UserID, comment.PostID, comment.ParentID, comment.Depth, comment.Text, comment.Approved, comment.Hidden)
 	return comment, err
 }
 
+func GetCommentByID(db *sql.DB, id string) (Comment, error) {
+
+	//get the comment by id from the database
+	comment, err := scanComment(db.QueryRow(`SELECT `+commentColumns+`
+												FROM comments
+												WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return comment, errors.New("Comment not found in the database")
+		}
+		return comment, err
+	}
+	return comment, nil
+}
+
+func UpdateComment(db *sql.DB, comment Comment, text string) (Comment, error) {
+
+	//keep the previous text in the edit history
+	if err := CreateCommentRevision(db, comment); err != nil {
+		return comment, err
+	}
+
+	//update the comment in the database, the held comment is hidden together with the new text
+	_, err := db.Exec(`UPDATE comments
+						SET text = ?, hidden = ?, edited = true, updated_at = ?
+						WHERE id = ?`, text, comment.Hidden, time.Now(), comment.ID)
+	if err != nil {
+		return comment, err
+	}
+	return GetCommentByID(db, comment.ID)
+}
+
+func SetCommentHidden(db *sql.DB, id string, hidden bool) error {
+
+	//hide the comment (moderation) or make it visible again
+	_, err := db.Exec(`UPDATE comments
+						SET hidden = ?
+						WHERE id = ?`, hidden, id)
+	return err
+}
+
+func ApproveComment(db *sql.DB, id string) error {
+
+	//approve the comment of the restricted user
+	_, err := db.Exec(`UPDATE comments
+						SET approved = true
+						WHERE id = ?`, id)
+	return err
+}
+
 func GetNumberOfComments(db *sql.DB, postID string) int {
 
-	//get the number of the comments for a specific post
+	//get the number of the approved comments for a specific post
+	var count int
+	db.QueryRow(`SELECT COUNT(*)
+					FROM comments
+					WHERE post_id = ? AND approved = true AND hidden = false AND deleted = false`, postID).Scan(&count)
+	return count
+
+}
+
+func NumberOfReplies(db *sql.DB, commentID string) int {
+
+	//get the number of all direct replies to the comment
 	var count int
-	db.QueryRow(`SELECT COUNT(*) 
-					FROM comments 
-					WHERE post_id = ?`, postID).Scan(&count)
+	db.QueryRow(`SELECT COUNT(*)
+					FROM comments
+					WHERE parent_id = ?`, commentID).Scan(&count)
 	return count
+}
+
+// prepareComment hides the author of the deleted comments and sets the options for the logged in user
+func prepareComment(comment CommentAPI, userID string) CommentAPI {
+	if comment.Deleted {
+		comment.Edited = false
+		comment.UserID = ""
+		comment.FirstName = ""
+		comment.LastName = ""
+		comment.UserPhotoURL = ""
+		return comment
+	}
+
+	if comment.UserID == userID || comment.PostOwner == userID {
+		comment.EnableDelete = true
+	}
+	if !comment.Approved && comment.PostOwner == userID {
+		comment.EnableApprove = true
+	}
+	comment.EnableReply = comment.Approved && comment.Depth < MaxCommentDepth
+	return comment
+}
 
+// addCommentDetails sets the reaction counts, the reaction of the logged in user and the mentions on every comment
+func addCommentDetails(db *sql.DB, comments []CommentAPI, userID string) {
+	var ids []string
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	counts := ReactionCountsByTargets(db, ReactionTargetComment, ids)
+	reactions := UserReactionsByTargets(db, ReactionTargetComment, ids, userID)
+	mentions := GetMentionsByTargets(db, MentionTargetComment, ids)
+	for i := range comments {
+		comments[i].Reactions = counts[comments[i].ID]
+		comments[i].MyReaction = reactions[comments[i].ID]
+		comments[i].Likes = comments[i].Reactions[ReactionLike]
+		comments[i].AlreadyLiked = comments[i].MyReaction == ReactionLike
+		if !comments[i].Deleted {
+			comments[i].Mentions = mentions[comments[i].ID]
+		}
+	}
+}
+
+func ValidCommentSort(order string) bool {
+	return order == CommentSortOldest || order == CommentSortTop
+}
+
+// SortCommentTree orders the comments on every level of the tree, the top order puts the most liked comments first
+func SortCommentTree(comments []CommentAPI, order string) []CommentAPI {
+	if order != CommentSortTop {
+		return comments
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Likes > comments[j].Likes
+	})
+	for i := range comments {
+		comments[i].Replies = SortCommentTree(comments[i].Replies, order)
+	}
+	return comments
+}
+
+// buildCommentTree nests the replies under their parent comments, the order of the comments is kept.
+// The replies to the left out comments (skipped maps them to their parents) are nested under the nearest shown ancestor.
+func buildCommentTree(comments []CommentAPI, skipped map[string]string) []CommentAPI {
+	children := make(map[string][]CommentAPI)
+	for _, comment := range comments {
+		parentID := comment.ParentID
+		for i := 0; i <= MaxCommentDepth; i++ {
+			ancestorID, found := skipped[parentID]
+			if !found {
+				break
+			}
+			parentID = ancestorID
+		}
+		children[parentID] = append(children[parentID], comment)
+	}
+
+	var attach func(parentID string) []CommentAPI
+	attach = func(parentID string) []CommentAPI {
+		replies := children[parentID]
+		for i := range replies {
+			replies[i].Replies = attach(replies[i].ID)
+			replies[i].ReplyCount = len(replies[i].Replies)
+		}
+		return replies
+	}
+	return attach("")
 }
 
 func CommentsByPostID(db *sql.DB, postID string, userID string) []CommentAPI {
 
+	//the replies to the hidden comments and to the comments of the blocked users are kept in the thread
+	skipped, err := getSkippedCommentParents(db, postID, userID)
+	if err != nil {
+		return nil
+	}
+
 	//get comments for the post
 	var comments []CommentAPI
-	rows, err := db.Query(`SELECT comments.id, comments.user_id, users.first_name, users.last_name, users.user_photo_url, comments.post_id, posts.user_id, comments.text, comments.created_at, comments.updated_at  
-							FROM comments 
-							LEFT JOIN users ON users.id = comments.user_id 
-							LEFT JOIN posts ON comments.post_id = posts.id 
-							WHERE comments.post_id = ? 
-							ORDER BY comments.created_at asc`, postID)
+	rows, err := db.Query(`SELECT `+commentAPIColumns+`
+							FROM comments
+							LEFT JOIN users ON users.id = comments.user_id
+							LEFT JOIN posts ON comments.post_id = posts.id
+							WHERE comments.post_id = ? AND comments.hidden = false
+								AND comments.user_id NOT IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+									UNION
+									SELECT user_block_id FROM blocks WHERE user_blocked_id = ?)
+							ORDER BY comments.created_at asc`, postID, userID, userID)
 	if err != nil {
 		return nil
 	}
@@ -68,42 +303,118 @@ func CommentsByPostID(db *sql.DB, postID string, userID string) []CommentAPI {
 
 	//loop through the rows of the result and fullfill comments slice
 	for rows.Next() {
-		var comment CommentAPI
-		if err := rows.
-			Scan(&comment.ID,
-				&comment.UserID,
-				&comment.FirstName,
-				&comment.LastName,
-				&comment.UserPhotoURL,
-				&comment.PostID,
-				&comment.PostOwner,
-				&comment.Text,
-				&comment.CreatedAt,
-				&comment.UpdatedAt); err != nil {
+		comment, err := scanCommentAPI(rows)
+		if err != nil {
 			return comments
 		}
-		comments = append(comments, comment)
+
+		//comments waiting for the approval are visible only to the comment author and the post owner
+		if !comment.Approved && comment.UserID != userID && comment.PostOwner != userID {
+			skipped[comment.ID] = comment.ParentID
+			continue
+		}
+		comments = append(comments, prepareComment(comment, userID))
 	}
 	if err = rows.Err(); err != nil {
 		return comments
 	}
 
-	//loop through all comments and add enable option for the users with the permission to delete comment
-	for i := 0; i < len(comments); i++ {
-		if comments[i].UserID == userID || comments[i].PostOwner == userID {
-			comments[i].EnableDelete = true
+	//return the comments with their reactions and mentions as a tree of replies
+	addCommentDetails(db, comments, userID)
+	return buildCommentTree(comments, skipped)
+}
+
+// getSkippedCommentParents maps the comments of the post that the user does not see to their parents,
+// these are the hidden comments and the comments of the users who blocked the user or were blocked by the user
+func getSkippedCommentParents(db *sql.DB, postID string, userID string) (map[string]string, error) {
+	parents := make(map[string]string)
+	rows, err := db.Query(`SELECT id, parent_id
+							FROM comments
+							WHERE post_id = ? AND (hidden = true OR user_id IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+								UNION
+								SELECT user_block_id FROM blocks WHERE user_blocked_id = ?))`, postID, userID, userID)
+	if err != nil {
+		return parents, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, parentID string
+		if err := rows.Scan(&id, &parentID); err != nil {
+			return parents, err
 		}
+		parents[id] = parentID
 	}
+	return parents, rows.Err()
+}
 
-	return comments
+func GetCommentReplies(db *sql.DB, commentID string, userID string, order string, limit int, offset int) ([]CommentAPI, error) {
+
+	//the top order puts the most liked replies first
+	orderBy := `comments.created_at asc`
+	if order == CommentSortTop {
+		orderBy = `(SELECT COUNT(*) FROM reactions WHERE reactions.target_type = 'comment' AND reactions.target_id = comments.id AND reactions.type = 'like') DESC, comments.created_at asc`
+	}
+
+	//get one page of the direct replies to the comment, with the number of their own replies,
+	//the replies of the users who blocked the user or were blocked by the user are left out
+	var replies []CommentAPI
+	rows, err := db.Query(`SELECT `+commentAPIColumns+`,
+								(SELECT COUNT(*) FROM comments r WHERE r.parent_id = comments.id AND r.hidden = false AND (r.approved = true OR r.user_id = ? OR posts.user_id = ?)
+									AND r.user_id NOT IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+										UNION
+										SELECT user_block_id FROM blocks WHERE user_blocked_id = ?))
+							FROM comments
+							LEFT JOIN users ON users.id = comments.user_id
+							LEFT JOIN posts ON comments.post_id = posts.id
+							WHERE comments.parent_id = ? AND comments.hidden = false AND (comments.approved = true OR comments.user_id = ? OR posts.user_id = ?)
+								AND comments.user_id NOT IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+									UNION
+									SELECT user_block_id FROM blocks WHERE user_blocked_id = ?)
+							ORDER BY `+orderBy+`
+							LIMIT ? OFFSET ?`, userID, userID, userID, userID, commentID, userID, userID, userID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill replies slice
+	for rows.Next() {
+		var reply CommentAPI
+		if err := rows.
+			Scan(&reply.ID,
+				&reply.UserID,
+				&reply.FirstName,
+				&reply.LastName,
+				&reply.UserPhotoURL,
+				&reply.PostID,
+				&reply.PostOwner,
+				&reply.ParentID,
+				&reply.Depth,
+				&reply.Text,
+				&reply.Approved,
+				&reply.Deleted,
+				&reply.Edited,
+				&reply.CreatedAt,
+				&reply.UpdatedAt,
+				&reply.ReplyCount); err != nil {
+			return replies, err
+		}
+		replies = append(replies, prepareComment(reply, userID))
+	}
+	if err = rows.Err(); err != nil {
+		return replies, err
+	}
+	addCommentDetails(db, replies, userID)
+	return replies, nil
 }
 
 func EnableDeleteComment(db *sql.DB, userID string, commentID string) bool {
 
 	//check if the logged in user is comment creator
 	var commentByUser Comment
-	db.QueryRow(`SELECT * 
-					FROM comments 
+	db.QueryRow(`SELECT id, user_id, post_id, text, created_at, updated_at
+					FROM comments
 					WHERE id = ? AND user_id = ?`, commentID, userID).
 		Scan(
 			&commentByUser.ID,
@@ -117,7 +428,7 @@ func EnableDeleteComment(db *sql.DB, userID string, commentID string) bool {
 	var commentByOwner Comment
 	db.QueryRow(`SELECT c.id, c.user_id, c.post_id, c.text, c.created_at, c.updated_at
 					FROM comments c
-					LEFT JOIN posts ON posts.id = c.post_id 
+					LEFT JOIN posts ON posts.id = c.post_id
 					WHERE c.id = ? AND posts.user_id = ?`, commentID, userID).
 		Scan(
 			&commentByOwner.ID,
@@ -138,9 +449,47 @@ func EnableDeleteComment(db *sql.DB, userID string, commentID string) bool {
 
 func DeleteComment(db *sql.DB, id string) error {
 
+	//get the comment, its parent may have to be cleaned up
+	comment, err := GetCommentByID(db, id)
+	if err != nil {
+		return err
+	}
+
+	//the last text is kept in the edit history for the moderators, the reactions and the mentions are removed
+	if !comment.Deleted {
+		if err := CreateCommentRevision(db, comment); err != nil {
+			return err
+		}
+	}
+	if err := DeleteMentions(db, MentionTargetComment, id); err != nil {
+		return err
+	}
+	if err := DeleteReactions(db, ReactionTargetComment, id); err != nil {
+		return err
+	}
+
+	//the comment with replies is replaced by a placeholder so that the thread is not orphaned
+	if NumberOfReplies(db, id) > 0 {
+		_, err := db.Exec(`UPDATE comments
+							SET text = ?, deleted = true, edited = false
+							WHERE id = ?`, DeletedCommentText, id)
+		return err
+	}
+
 	//delete the comment in the database
-	_, err := db.Exec(`DELETE 
+	_, err = db.Exec(`DELETE
 						FROM comments
 						WHERE id = ?`, id)
-	return err
+	if err != nil {
+		return err
+	}
+
+	//remove the placeholder of the parent when its last reply is deleted
+	if comment.ParentID != "" {
+		parent, err := GetCommentByID(db, comment.ParentID)
+		if err == nil && parent.Deleted && NumberOfReplies(db, parent.ID) == 0 {
+			return DeleteComment(db, parent.ID)
+		}
+	}
+	return nil
 }
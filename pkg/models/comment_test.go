@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBuildCommentTree(t *testing.T) {
+	comments := []CommentAPI{
+		{ID: "1"},
+		{ID: "2"},
+		{ID: "3", ParentID: "1", Depth: 1},
+		{ID: "4", ParentID: "3", Depth: 2},
+		{ID: "5", ParentID: "1", Depth: 1},
+		{ID: "6", ParentID: "missing", Depth: 1},
+	}
+
+	tree := buildCommentTree(comments, nil)
+	if len(tree) != 2 || tree[0].ID != "1" || tree[1].ID != "2" {
+		t.Fatalf("buildCommentTree() top level = %v, want comments 1 and 2", tree)
+	}
+	if tree[0].ReplyCount != 2 || tree[0].Replies[0].ID != "3" || tree[0].Replies[1].ID != "5" {
+		t.Errorf("buildCommentTree() replies of 1 = %v, want 3 and 5", tree[0].Replies)
+	}
+	if tree[0].Replies[0].ReplyCount != 1 || tree[0].Replies[0].Replies[0].ID != "4" {
+		t.Errorf("buildCommentTree() replies of 3 = %v, want 4", tree[0].Replies[0].Replies)
+	}
+	if tree[1].ReplyCount != 0 || tree[1].Replies != nil {
+		t.Errorf("buildCommentTree() replies of 2 = %v, want none", tree[1].Replies)
+	}
+}
+
+func TestBuildCommentTreeKeepsRepliesOfSkippedComments(t *testing.T) {
+	comments := []CommentAPI{
+		{ID: "1"},
+		{ID: "4", ParentID: "3", Depth: 2},
+		{ID: "5", ParentID: "hidden-top", Depth: 1},
+	}
+
+	//comment 2 is hidden and comment 3 waits for the approval, so reply 4 is shown under comment 1
+	skipped := map[string]string{"2": "1", "3": "2", "hidden-top": ""}
+	tree := buildCommentTree(comments, skipped)
+	if len(tree) != 2 || tree[0].ID != "1" || tree[1].ID != "5" {
+		t.Fatalf("buildCommentTree() top level = %v, want comments 1 and 5", tree)
+	}
+	if tree[0].ReplyCount != 1 || tree[0].Replies[0].ID != "4" {
+		t.Errorf("buildCommentTree() replies of 1 = %v, want 4", tree[0].Replies)
+	}
+}
+
+func TestSortCommentTree(t *testing.T) {
+	comments := []CommentAPI{
+		{ID: "1", Likes: 1, Replies: []CommentAPI{{ID: "4"}, {ID: "5", Likes: 3}}},
+		{ID: "2", Likes: 5},
+		{ID: "3", Likes: 1},
+	}
+
+	oldest := SortCommentTree(append([]CommentAPI(nil), comments...), CommentSortOldest)
+	if oldest[0].ID != "1" || oldest[1].ID != "2" || oldest[2].ID != "3" {
+		t.Errorf("SortCommentTree(oldest) = %v, want the original order", oldest)
+	}
+
+	top := SortCommentTree(comments, CommentSortTop)
+	if top[0].ID != "2" || top[1].ID != "1" || top[2].ID != "3" {
+		t.Errorf("SortCommentTree(top) = %v, want 2, 1, 3", top)
+	}
+	if top[1].Replies[0].ID != "5" {
+		t.Errorf("SortCommentTree(top) replies = %v, want 5 first", top[1].Replies)
+	}
+}
+
+func TestCommentsByPostIDLeavesOutBlockedUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//the comment of the blocked user is skipped, the reply to it is kept under its parent
+	mock.ExpectQuery("FROM comments WHERE post_id = \\? AND \\(hidden = true OR user_id IN \\(SELECT user_blocked_id FROM blocks").
+		WithArgs("post", "viewer", "viewer").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow("blocked", "first"))
+	mock.ExpectQuery("AND comments.user_id NOT IN \\(SELECT user_blocked_id FROM blocks").
+		WithArgs("post", "viewer", "viewer").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "first_name", "last_name", "user_photo_url", "post_id", "post_owner", "parent_id", "depth", "text", "approved", "deleted", "edited", "created_at", "updated_at"}).
+			AddRow("first", "owner", "", "", "", "post", "owner", "", 0, "First", true, false, false, time.Now(), time.Now()).
+			AddRow("reply", "friend", "", "", "", "post", "owner", "blocked", 2, "Reply", true, false, false, time.Now(), time.Now()))
+	mock.ExpectQuery("FROM reactions").WillReturnRows(sqlmock.NewRows([]string{"target_id", "type", "count"}))
+	mock.ExpectQuery("FROM reactions").WillReturnRows(sqlmock.NewRows([]string{"target_id", "type"}))
+	mock.ExpectQuery("FROM mentions").WillReturnRows(sqlmock.NewRows([]string{"target_id", "user_id", "username", "offset", "length"}))
+
+	comments := CommentsByPostID(db, "post", "viewer")
+	if len(comments) != 1 || comments[0].ID != "first" || len(comments[0].Replies) != 1 || comments[0].Replies[0].ID != "reply" {
+		t.Errorf("the reply should be kept under the first comment, got %+v", comments)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
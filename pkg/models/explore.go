@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ExplorePeriod is how old the posts in the explore feed can be
+const ExplorePeriod = 7 * 24 * time.Hour
+
+// exploreCounts joins the posts with their reactions, comments and shares counted once per table,
+// only the engagement after the given time is counted because the older posts are not in the explore feed
+const exploreCounts = `LEFT JOIN (SELECT target_id, COUNT(*) AS count
+					FROM reactions
+					WHERE target_type = 'post' AND created_at >= ?
+					GROUP BY target_id) AS reaction_counts ON reaction_counts.target_id = posts.id
+				LEFT JOIN (SELECT post_id, COUNT(*) AS count
+					FROM comments
+					WHERE hidden = false AND deleted = false AND created_at >= ?
+					GROUP BY post_id) AS comment_counts ON comment_counts.post_id = posts.id
+				LEFT JOIN (SELECT repost_of_id, COUNT(*) AS count
+					FROM posts
+					WHERE repost_of_id <> '' AND hidden = false AND status = 'published' AND created_at >= ?
+					GROUP BY repost_of_id) AS repost_counts ON repost_counts.repost_of_id = posts.id`
+
+// exploreEngagement is the engagement of the post in the explore feed, the comments and the shares count more than the reactions
+const exploreEngagement = `(COALESCE(reaction_counts.count, 0) + 2 * COALESCE(comment_counts.count, 0) + 3 * COALESCE(repost_counts.count, 0))`
+
+// GetExplorePosts returns one page of the most popular recent posts of the public profiles, optionally only the posts with the hashtag.
+// The own posts and the posts of the blocked and the muted users are skipped.
+func GetExplorePosts(db *sql.DB, viewerID string, tag string, now time.Time, limit int, offset int) ([]Post, error) {
+
+	//filter by the hashtag when it is given
+	since := now.Add(-ExplorePeriod)
+	hashtagJoin := ""
+	args := []interface{}{}
+	if tag != "" {
+		hashtagJoin = `INNER JOIN post_hashtags ON post_hashtags.post_id = posts.id AND post_hashtags.tag = ?`
+		args = append(args, tag)
+	}
+	args = append(args, since, since, since, since, viewerID, viewerID, viewerID, viewerID, limit, offset)
+
+	//get the posts ordered by the engagement, the newest first when the engagement is equal,
+	//the posts of the public profiles that are not blocked are visible to the viewer, so they are not checked again
+	return scanPosts(db.Query(`SELECT `+postColumns+`
+								FROM posts
+								INNER JOIN users ON users.id = posts.user_id
+								`+hashtagJoin+`
+								`+exploreCounts+`
+								WHERE users.profile_type = 'public' AND posts.hidden = false AND posts.status = 'published'
+									AND posts.created_at >= ? AND posts.user_id <> ?
+									AND posts.user_id NOT IN (SELECT user_muted_id FROM mutes WHERE user_mute_id = ?)
+									AND posts.user_id NOT IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+										UNION
+										SELECT user_block_id FROM blocks WHERE user_blocked_id = ?)
+								ORDER BY `+exploreEngagement+` DESC, posts.created_at DESC
+								LIMIT ? OFFSET ?`, args...))
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetExplorePostsFiltersByHashtag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-ExplorePeriod)
+	columns := []string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}
+
+	//without the hashtag the posts table is not joined with the hashtags
+	mock.ExpectQuery("WHERE users.profile_type = 'public'").
+		WithArgs(since, since, since, since, "viewer", "viewer", "viewer", "viewer", 20, 0).
+		WillReturnRows(sqlmock.NewRows(columns))
+	if posts, err := GetExplorePosts(db, "viewer", "", now, 20, 0); err != nil || len(posts) != 0 {
+		t.Errorf("expected no posts, got %v %v", posts, err)
+	}
+
+	//the hashtag is the first argument of the query
+	mock.ExpectQuery("INNER JOIN post_hashtags").
+		WithArgs("travel", since, since, since, since, "viewer", "viewer", "viewer", "viewer", 20, 20).
+		WillReturnRows(sqlmock.NewRows(columns))
+	if _, err := GetExplorePosts(db, "viewer", "travel", now, 20, 20); err != nil {
+		t.Error(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetExplorePostsRanksByJoinedCounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//the counts are joined once per table instead of being counted for every post, and the posts are not checked again
+	mock.ExpectQuery(`LEFT JOIN \(SELECT target_id, COUNT\(\*\) AS count FROM reactions .* ORDER BY \(COALESCE\(reaction_counts.count, 0\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+			AddRow("popular", "", "", "author", "", false, PostStatusPublished, nil, nil, nil, time.Now(), time.Now()))
+	posts, err := GetExplorePosts(db, "viewer", "", time.Now(), 20, 0)
+	if err != nil || len(posts) != 1 {
+		t.Errorf("expected the popular post, got %v %v", posts, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/feed"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// FeedCandidateAge is how old the posts of the friends in the ranked feed can be
+	FeedCandidateAge = 7 * 24 * time.Hour
+	// OwnPostsFeedAge is how long the own posts are shown in the ranked feed
+	OwnPostsFeedAge = 24 * time.Hour
+	// maxFeedCandidates limits the posts that are ranked on one request
+	maxFeedCandidates = 500
+	// the interactions of the last month count for the affinity, the reactions and the comments of the last day for the engagement
+	affinityPeriod   = 30 * 24 * time.Hour
+	engagementPeriod = 24 * time.Hour
+)
+
+// GetFeedCandidates returns the live posts that can be ranked in the home feed of the viewer,
+// the recent posts from the timeline and the own posts of the last day. The posts created after now are left out,
+// so that the pages ranked with the same time do not shift.
+func GetFeedCandidates(db *sql.DB, viewerID string, now time.Time) ([]Post, error) {
+
+	//get the recent posts from the timeline of the viewer
+	posts, err := GetTimelinePosts(db, viewerID, now.Add(-FeedCandidateAge), maxFeedCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	//add the own posts of the last day
+	ownPosts, err := scanPosts(db.Query(`SELECT `+postColumns+`
+											FROM posts
+											WHERE user_id = ? AND created_at >= ? AND created_at <= ? AND hidden = false AND status = 'published'
+											ORDER BY created_at DESC`, viewerID, now.Add(-OwnPostsFeedAge), now))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Post
+	for _, post := range mergePosts(posts, ownPosts, maxFeedCandidates) {
+		if !post.CreatedAt.After(now) {
+			candidates = append(candidates, post)
+		}
+	}
+	return candidates, nil
+}
+
+// addCounts runs the query that returns the id and the count, and adds the counts to the map
+func addCounts(db *sql.DB, counts map[string]float64, weight float64, query string, args ...interface{}) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return err
+		}
+		counts[id] += weight * float64(count)
+	}
+	return rows.Err()
+}
+
+// GetAuthorAffinity counts the interactions of the viewer with every author before now, the comments and the messages count more than the reactions
+func GetAuthorAffinity(db *sql.DB, viewerID string, now time.Time) (map[string]float64, error) {
+	affinity := make(map[string]float64)
+	since := now.Add(-affinityPeriod)
+
+	//the reactions on the posts of the authors
+	if err := addCounts(db, affinity, 1, `SELECT posts.user_id, COUNT(*)
+											FROM reactions
+											INNER JOIN posts ON posts.id = reactions.target_id
+											WHERE reactions.target_type = ? AND reactions.user_id = ? AND reactions.created_at >= ? AND reactions.created_at < ?
+											GROUP BY posts.user_id`, ReactionTargetPost, viewerID, since, now); err != nil {
+		return affinity, err
+	}
+
+	//the comments on the posts of the authors
+	if err := addCounts(db, affinity, 2, `SELECT posts.user_id, COUNT(*)
+											FROM comments
+											INNER JOIN posts ON posts.id = comments.post_id
+											WHERE comments.user_id = ? AND comments.created_at >= ? AND comments.created_at < ?
+											GROUP BY posts.user_id`, viewerID, since, now); err != nil {
+		return affinity, err
+	}
+
+	//the messages sent to the authors
+	err := addCounts(db, affinity, 0.5, `SELECT IF(rooms.user1_id = ?, rooms.user2_id, rooms.user1_id), COUNT(*)
+											FROM messages
+											INNER JOIN rooms ON rooms.id = messages.room_id
+											WHERE messages.sender = ? AND messages.created_at >= ? AND messages.created_at < ?
+											GROUP BY rooms.id`, viewerID, viewerID, since, now)
+	return affinity, err
+}
+
+// GetEngagementCounts counts the reactions and the comments that the posts got lately, before now
+func GetEngagementCounts(db *sql.DB, postIDs []string, now time.Time) (map[string]float64, error) {
+	engagement := make(map[string]float64)
+	if len(postIDs) == 0 {
+		return engagement, nil
+	}
+	since := now.Add(-engagementPeriod)
+
+	query, args, _ := sqlx.In(`SELECT target_id, COUNT(*)
+								FROM reactions
+								WHERE target_type = ? AND target_id IN (?) AND created_at >= ? AND created_at < ?
+								GROUP BY target_id`, ReactionTargetPost, postIDs, since, now)
+	if err := addCounts(db, engagement, 1, query, args...); err != nil {
+		return engagement, err
+	}
+
+	query, args, _ = sqlx.In(`SELECT post_id, COUNT(*)
+								FROM comments
+								WHERE post_id IN (?) AND hidden = false AND deleted = false AND created_at >= ? AND created_at < ?
+								GROUP BY post_id`, postIDs, since, now)
+	err := addCounts(db, engagement, 1, query, args...)
+	return engagement, err
+}
+
+// RankFeed orders the posts for the viewer, the own posts have the same affinity as the closest friend
+func RankFeed(db *sql.DB, posts []Post, viewerID string, now time.Time) ([]Post, error) {
+	affinity, err := GetAuthorAffinity(db, viewerID, now)
+	if err != nil {
+		return nil, err
+	}
+	var postIDs []string
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	engagement, err := GetEngagementCounts(db, postIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	maxAffinity := 1.0
+	for _, value := range affinity {
+		if value > maxAffinity {
+			maxAffinity = value
+		}
+	}
+
+	//rank the candidates
+	postsByID := make(map[string]Post)
+	var candidates []feed.Candidate
+	for _, post := range posts {
+		postsByID[post.ID] = post
+		candidate := feed.Candidate{
+			PostID:     post.ID,
+			AuthorID:   post.UserID,
+			CreatedAt:  post.CreatedAt,
+			HasPhoto:   post.Photo != "",
+			IsRepost:   post.RepostOfID != "",
+			Affinity:   affinity[post.UserID],
+			Engagement: int(engagement[post.ID]),
+		}
+		if post.UserID == viewerID {
+			candidate.Affinity = maxAffinity
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	var ranked []Post
+	for _, candidate := range feed.Rank(candidates, feed.DefaultWeights, now) {
+		ranked = append(ranked, postsByID[candidate.PostID])
+	}
+	return ranked, nil
+}
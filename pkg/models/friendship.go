@@ -140,7 +140,7 @@ func NumberOfFriends(db *sql.DB, id string) int {
 func GetFriendsIDs(db *sql.DB, searchUserID string) []string {
 
 	//get all friends
-	friends := GetFriends(initializers.DB, searchUserID)
+	friends := GetFriends(db, searchUserID)
 
 	//create a slice with only friends ids
 	var friendsIDs []string
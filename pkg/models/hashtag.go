@@ -0,0 +1,144 @@
+package models
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// PostHashtag is one hashtag of the post in the hashtag index
+type PostHashtag struct {
+	ID     string `json:"id" gorm:"primaryKey"`
+	PostID string `json:"post_id" gorm:"type:varchar(191);index"`
+	Tag    string `json:"tag" gorm:"type:varchar(191);index"`
+}
+
+// HashtagAPI is the hashtag with the number of the posts that use it
+type HashtagAPI struct {
+	Tag       string `json:"tag"`
+	PostCount int    `json:"post_count"`
+}
+
+// the hashtag starts after a space or a punctuation, so that the links (page#part) and the html entities (&#39;) are not matched
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#([\p{L}\p{N}_]{1,100})`)
+
+// ExtractHashtags returns the lowercase hashtags of the text without duplicates, tags made only of digits are not hashtags
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(match[1])
+		if seen[tag] || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NormalizeHashtag turns the tag from the url (with or without #) into the form that is kept in the index
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func SetPostHashtags(db *sql.DB, postID string, text string) error {
+	return setPostHashtags(db, postID, text)
+}
+
+func setPostHashtags(db execer, postID string, text string) error {
+
+	//remove the old hashtags of the post
+	if err := deletePostHashtags(db, postID); err != nil {
+		return err
+	}
+
+	//index the hashtags found in the text
+	for _, tag := range ExtractHashtags(text) {
+		if _, err := db.Exec(`INSERT INTO post_hashtags (id, post_id, tag)
+								VALUES (?, ?, ?)`, uuid.New().String(), postID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func DeletePostHashtags(db *sql.DB, postID string) error {
+	return deletePostHashtags(db, postID)
+}
+
+func deletePostHashtags(db execer, postID string) error {
+
+	//remove the post from the hashtag index
+	_, err := db.Exec(`DELETE
+						FROM post_hashtags
+						WHERE post_id = ?`, postID)
+	return err
+}
+
+func GetPostsByHashtag(db *sql.DB, tag string, viewerID string, limit int, offset int) ([]Post, error) {
+
+	//get one page of the posts with the hashtag that the viewer is allowed to see, the newest first
+	var posts []Post
+	args := append([]interface{}{tag}, postVisibleArgs(viewerID)...)
+	rows, err := db.Query(`SELECT `+postColumns+`
+							FROM post_hashtags
+							INNER JOIN posts ON posts.id = post_hashtags.post_id
+							INNER JOIN users ON users.id = posts.user_id
+							WHERE post_hashtags.tag = ? AND `+postVisibleCondition+`
+							ORDER BY posts.created_at DESC
+							LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the posts
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return posts, err
+		}
+		posts = append(posts, post)
+	}
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+	return posts, nil
+}
+
+func GetTrendingHashtags(db *sql.DB, since time.Time, limit int) ([]HashtagAPI, error) {
+
+	//get the hashtags used in the most posts created after the given time, the trends are the same for every user,
+	//so only the posts of the public profiles are counted
+	var hashtags []HashtagAPI
+	rows, err := db.Query(`SELECT post_hashtags.tag, COUNT(DISTINCT post_hashtags.post_id)
+							FROM post_hashtags
+							INNER JOIN posts ON posts.id = post_hashtags.post_id
+							INNER JOIN users ON users.id = posts.user_id
+							WHERE posts.created_at >= ? AND posts.hidden = false AND posts.status = 'published' AND users.profile_type = 'public'
+							GROUP BY post_hashtags.tag
+							ORDER BY COUNT(DISTINCT post_hashtags.post_id) DESC, post_hashtags.tag ASC
+							LIMIT ?`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the hashtags slice
+	for rows.Next() {
+		var hashtag HashtagAPI
+		if err := rows.Scan(&hashtag.Tag, &hashtag.PostCount); err != nil {
+			return hashtags, err
+		}
+		hashtags = append(hashtags, hashtag)
+	}
+	if err = rows.Err(); err != nil {
+		return hashtags, err
+	}
+	return hashtags, nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "Hashtags are lowercase", text: "#Summer at the #beach", want: []string{"summer", "beach"}},
+		{name: "Duplicates are removed", text: "#go #Go #GO", want: []string{"go"}},
+		{name: "Punctuation ends the hashtag", text: "Great day (#sunny)!", want: []string{"sunny"}},
+		{name: "Unicode letters are kept", text: "#Sarajevo #ćevapi", want: []string{"sarajevo", "ćevapi"}},
+		{name: "Numbers alone are not hashtags", text: "We are #1 #2022", want: nil},
+		{name: "Links and entities are not hashtags", text: "see http://a.com/page#part and it&#39;s", want: nil},
+		{name: "Hashtag inside a word is not a hashtag", text: "abc#def", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractHashtags(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractHashtags(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPostsByHashtagFiltersInQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//the privacy and the blocks are checked before the page is cut, so no post is checked afterwards
+	viewerID := "viewer-id"
+	mock.ExpectQuery(`WHERE post_hashtags.tag = \? AND posts.hidden = false .* FROM blocks .* LIMIT \? OFFSET \?`).
+		WithArgs("summer", viewerID, viewerID, viewerID, viewerID, viewerID, 10, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+			AddRow("post-id", "", "#summer", "author-id", "", false, PostStatusPublished, nil, nil, nil, time.Now(), time.Now()))
+
+	posts, err := GetPostsByHashtag(db, "summer", viewerID, 10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != "post-id" {
+		t.Errorf("GetPostsByHashtag() = %v, want post-id", posts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetTrendingHashtagsCountsOnlyPublicPosts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//the #secret of the private profile is left out by the query, so only the public #summer is returned
+	since := time.Now().Add(-24 * time.Hour)
+	mock.ExpectQuery(`INNER JOIN users ON users.id = posts.user_id .* AND users.profile_type = 'public' GROUP BY`).
+		WithArgs(since, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "count"}).AddRow("summer", 3))
+
+	hashtags, err := GetTrendingHashtags(db, since, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashtags) != 1 || hashtags[0].Tag != "summer" || hashtags[0].PostCount != 3 {
+		t.Errorf("GetTrendingHashtags() = %v, want only summer", hashtags)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
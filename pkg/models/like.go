@@ -3,10 +3,9 @@ package models
 import (
 	"database/sql"
 	"time"
-
-	"github.com/google/uuid"
 )
 
+// Like is the old like toggle, the likes are migrated into the reactions by MigrateLikes and a like is now a reaction of the like type
 type Like struct {
 	ID        string    `json:"id" gorm:"primaryKey"`
 	UserID    string    `json:"user_id"  gorm:"type:varchar(191)"`
@@ -16,79 +15,69 @@ type Like struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-func CheckLike(db *sql.DB, postID string, userID string) (Like, error) {
-
-	//check whether there is already a record about the like
-	var like Like
-	if err := db.QueryRow(`SELECT * 
-							FROM likes 
-							WHERE post_id = ? AND user_id = ?`, postID, userID).
-		Scan(
-			&like.ID,
-			&like.UserID,
-			&like.PostID,
-			&like.CreatedAt,
-			&like.UpdatedAt,
-			&like.LikeBool); err != nil {
-		if err == sql.ErrNoRows {
-			return like, nil
-		}
-		return like, err
-	}
-	return like, nil
+// LikerAPI is the user that liked the post, with the name and the avatar like Author
+type LikerAPI struct {
+	UserID string `json:"user_id"`
+	Author
+	IsFriend bool      `json:"is_friend"`
+	LikedAt  time.Time `json:"liked_at"`
 }
 
 func CheckIfAlreadyLiked(db *sql.DB, postID string, userID string) bool {
 
 	//check whether the user already liked the post
-	var like Like
-	if err := db.QueryRow(`SELECT * 
-							FROM likes 
-							WHERE post_id = ? AND user_id = ? AND like_bool = true`, postID, userID).
-		Scan(
-			&like.ID,
-			&like.UserID,
-			&like.PostID,
-			&like.CreatedAt,
-			&like.UpdatedAt,
-			&like.LikeBool); err != nil {
-		if err == sql.ErrNoRows {
-			return false
-		}
-		return false
-	}
-	return true
+	reaction, _ := GetReaction(db, ReactionTargetPost, postID, userID)
+	return reaction.Type == ReactionLike
 }
 
-func LikePost(db *sql.DB, loggedInUserID string, postID string) (Like, error) {
+func LikeCount(db *sql.DB, id string) int {
 
-	//save the like in the database
-	likeID := uuid.New().String()
-	like := Like{ID: likeID, UserID: loggedInUserID, PostID: postID, LikeBool: true}
-	_, err := db.Exec(`INSERT INTO likes (id, user_id, post_id, like_bool) 
-						VALUES (?, ?, ?, ?)`, likeID, loggedInUserID, postID, true)
-	return like, err
+	//get the number of the likes on the post
+	var count int
+	db.QueryRow(`SELECT COUNT(*) 
+					FROM reactions 
+					WHERE target_type = ? AND target_id = ? AND type = ?`, ReactionTargetPost, id, ReactionLike).Scan(&count)
+	return count
 }
 
-func UpdateLike(db *sql.DB, like Like, likeBool bool) error {
+func GetPostLikers(db *sql.DB, postID string, viewerID string, limit int, offset int) ([]LikerAPI, error) {
 
-	//like/unlike already created like
-	sqlStatement := `UPDATE likes
-						SET like_bool = ?
-						WHERE id = ?`
-	_, err := db.Exec(sqlStatement, likeBool, like.ID)
+	//get one page of the users that liked the post, friends of the viewer first, users blocked in either direction are left out
+	var likers []LikerAPI
+	rows, err := db.Query(`SELECT reactions.user_id, users.first_name, users.last_name, users.user_photo_url, reactions.created_at,
+								EXISTS (SELECT 1 FROM friendships
+										WHERE friendships.status = 'accepted'
+											AND ((friendships.user_sent_req_id = ? AND friendships.user_got_req_id = reactions.user_id)
+												OR (friendships.user_got_req_id = ? AND friendships.user_sent_req_id = reactions.user_id))) AS is_friend
+							FROM reactions
+							INNER JOIN users ON users.id = reactions.user_id
+							WHERE reactions.target_type = ? AND reactions.target_id = ? AND reactions.type = ?
+								AND NOT EXISTS (SELECT 1 FROM blocks
+												WHERE (blocks.user_block_id = ? AND blocks.user_blocked_id = reactions.user_id)
+													OR (blocks.user_block_id = reactions.user_id AND blocks.user_blocked_id = ?))
+							ORDER BY is_friend DESC, reactions.created_at DESC
+							LIMIT ? OFFSET ?`, viewerID, viewerID, ReactionTargetPost, postID, ReactionLike, viewerID, viewerID, limit, offset)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
-
-func LikeCount(db *sql.DB, id string) int {
+	defer rows.Close()
 
-	//get the number of the likes on the post
-	var count int
-	db.QueryRow(`SELECT COUNT(*) 
-					FROM likes 
-					WHERE post_id = ? AND like_bool = true`, id).Scan(&count)
-	return count
+	//loop through the rows of the result and fullfill the likers slice
+	for rows.Next() {
+		var liker LikerAPI
+		if err := rows.
+			Scan(&liker.UserID,
+				&liker.FirstName,
+				&liker.LastName,
+				&liker.UserPhotoURL,
+				&liker.LikedAt,
+				&liker.IsFriend); err != nil {
+			return likers, err
+		}
+		likers = append(likers, liker)
+	}
+	if err = rows.Err(); err != nil {
+		return likers, err
+	}
+	return likers, nil
 }
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/linkpreview"
+)
+
+// LinkPreview is the cached preview of the link, the links without a preview are cached as failed so they are not fetched again and again
+type LinkPreview struct {
+	ID          string    `json:"-" gorm:"primaryKey"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ImageURL    string    `json:"image_url"`
+	SiteName    string    `json:"site_name"`
+	Failed      bool      `json:"-" gorm:"default:false"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// linkPreviewID is the key of the link in the cache, the links can be longer than the indexed columns
+func linkPreviewID(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetLinkPreview(db *sql.DB, url string) (LinkPreview, error) {
+
+	//get the cached preview of the link
+	var preview LinkPreview
+	if err := db.QueryRow(`SELECT id, url, title, description, image_url, site_name, failed, fetched_at
+							FROM link_previews
+							WHERE id = ?`, linkPreviewID(url)).
+		Scan(
+			&preview.ID,
+			&preview.URL,
+			&preview.Title,
+			&preview.Description,
+			&preview.ImageURL,
+			&preview.SiteName,
+			&preview.Failed,
+			&preview.FetchedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return preview, errors.New("Link preview not found in the database")
+		}
+		return preview, err
+	}
+	return preview, nil
+}
+
+func SaveLinkPreview(db *sql.DB, url string, preview linkpreview.Preview, failed bool, fetchedAt time.Time) error {
+
+	//save the preview, the old preview of the link is replaced
+	_, err := db.Exec(`INSERT INTO link_previews (id, url, title, description, image_url, site_name, failed, fetched_at)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+						ON DUPLICATE KEY UPDATE title = VALUES(title), description = VALUES(description), image_url = VALUES(image_url),
+							site_name = VALUES(site_name), failed = VALUES(failed), fetched_at = VALUES(fetched_at)`,
+		linkPreviewID(url), url, preview.Title, preview.Description, preview.ImageURL, preview.SiteName, failed, fetchedAt)
+	return err
+}
+
+// LinkPreviewOf returns the cached preview of the first link in the text, or nil when it is not fetched yet or has no preview
+func LinkPreviewOf(db *sql.DB, text string) *LinkPreview {
+	urls := linkpreview.ExtractURLs(text)
+	if len(urls) == 0 {
+		return nil
+	}
+	preview, err := GetLinkPreview(db, urls[0])
+	if err != nil || preview.Failed {
+		return nil
+	}
+	return &preview
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// types of the content that can mention users
+const (
+	MentionTargetPost    = "post"
+	MentionTargetComment = "comment"
+)
+
+// Mention is the @username in the text resolved to the user, the offset and the length are counted in characters
+type Mention struct {
+	ID         string `json:"-" gorm:"primaryKey"`
+	TargetType string `json:"-" gorm:"type:varchar(191);index:idx_mention_target"`
+	TargetID   string `json:"-" gorm:"type:varchar(191);index:idx_mention_target"`
+	UserID     string `json:"user_id" gorm:"type:varchar(191)"`
+	Username   string `json:"username" gorm:"-"`
+	Offset     int    `json:"offset"`
+	Length     int    `json:"length"`
+}
+
+// the mention starts after a space or a punctuation, so that the emails (name@example.com) are not matched
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([A-Za-z0-9_.]{1,100})`)
+
+// ExtractMentions returns the @usernames found in the text with their positions, the users are not resolved
+func ExtractMentions(text string) []Mention {
+	var mentions []Mention
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+
+		//the dot at the end belongs to the sentence, not to the username
+		username := strings.TrimRight(text[match[2]:match[3]], ".")
+		if username == "" {
+			continue
+		}
+		start := match[2] - 1
+		mentions = append(mentions, Mention{
+			Username: username,
+			Offset:   utf8.RuneCountInString(text[:start]),
+			Length:   utf8.RuneCountInString(username) + 1,
+		})
+	}
+	return mentions
+}
+
+func ResolveMentions(db *sql.DB, text string) ([]Mention, error) {
+
+	//find the mentioned usernames in the text
+	mentions := ExtractMentions(text)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	var usernames []string
+	for _, mention := range mentions {
+		usernames = append(usernames, mention.Username)
+	}
+
+	//get the ids of the users with those usernames
+	query, args, _ := sqlx.In(`SELECT id, username
+								FROM users
+								WHERE username IN (?)`, usernames)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make(map[string]string)
+	for rows.Next() {
+		var id, username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		userIDs[strings.ToLower(username)] = id
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//keep only the mentions of the existing users
+	var resolved []Mention
+	for _, mention := range mentions {
+		if id, ok := userIDs[strings.ToLower(mention.Username)]; ok {
+			mention.UserID = id
+			resolved = append(resolved, mention)
+		}
+	}
+	return resolved, nil
+}
+
+func SaveMentions(db *sql.DB, targetType string, targetID string, text string) ([]Mention, error) {
+
+	//resolve the mentions in the text
+	mentions, err := ResolveMentions(db, text)
+	if err != nil {
+		return nil, err
+	}
+
+	//replace the old mentions of the content
+	if err := DeleteMentions(db, targetType, targetID); err != nil {
+		return nil, err
+	}
+	for i := range mentions {
+		mentions[i].ID = uuid.New().String()
+		mentions[i].TargetType = targetType
+		mentions[i].TargetID = targetID
+		if _, err := db.Exec(`INSERT INTO mentions (id, target_type, target_id, user_id, offset, length)
+								VALUES (?, ?, ?, ?, ?, ?)`, mentions[i].ID, targetType, targetID, mentions[i].UserID, mentions[i].Offset, mentions[i].Length); err != nil {
+			return mentions, err
+		}
+	}
+	return mentions, nil
+}
+
+func DeleteMentions(db *sql.DB, targetType string, targetID string) error {
+
+	//remove the mentions of the content
+	_, err := db.Exec(`DELETE
+						FROM mentions
+						WHERE target_type = ? AND target_id = ?`, targetType, targetID)
+	return err
+}
+
+func GetMentions(db *sql.DB, targetType string, targetID string) []Mention {
+
+	//get the mentions of one content
+	return GetMentionsByTargets(db, targetType, []string{targetID})[targetID]
+}
+
+func GetMentionsByTargets(db *sql.DB, targetType string, targetIDs []string) map[string][]Mention {
+
+	//get the mentions of every content with the current usernames of the mentioned users
+	mentions := make(map[string][]Mention)
+	if len(targetIDs) == 0 {
+		return mentions
+	}
+	query, args, _ := sqlx.In(`SELECT mentions.target_id, mentions.user_id, users.username, mentions.offset, mentions.length
+								FROM mentions
+								INNER JOIN users ON users.id = mentions.user_id
+								WHERE mentions.target_type = ? AND mentions.target_id IN (?)
+								ORDER BY mentions.offset ASC`, targetType, targetIDs)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return mentions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mention Mention
+		if err := rows.Scan(&mention.TargetID, &mention.UserID, &mention.Username, &mention.Offset, &mention.Length); err != nil {
+			return mentions
+		}
+		mention.TargetType = targetType
+		mentions[mention.TargetID] = append(mentions[mention.TargetID], mention)
+	}
+	return mentions
+}
+
+// NewlyMentionedUsers returns the users mentioned in the new version of the content that were not mentioned before
+func NewlyMentionedUsers(previous []Mention, current []Mention) []string {
+	seen := make(map[string]bool)
+	for _, mention := range previous {
+		seen[mention.UserID] = true
+	}
+	var users []string
+	for _, mention := range current {
+		if !seen[mention.UserID] {
+			seen[mention.UserID] = true
+			users = append(users, mention.UserID)
+		}
+	}
+	return users
+}
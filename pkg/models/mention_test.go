@@ -0,0 +1,35 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Mention
+	}{
+		{name: "Mention at the start", text: "@dika hello", want: []Mention{{Username: "dika", Offset: 0, Length: 5}}},
+		{name: "Dot at the end is not a part of the username", text: "Thanks @dika.bosnjak.", want: []Mention{{Username: "dika.bosnjak", Offset: 7, Length: 13}}},
+		{name: "Offsets are counted in characters", text: "Čao @ana, @marko", want: []Mention{{Username: "ana", Offset: 4, Length: 4}, {Username: "marko", Offset: 10, Length: 6}}},
+		{name: "Emails are not mentions", text: "write to dika@example.com", want: nil},
+		{name: "Lone @ is not a mention", text: "meet @ 5", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractMentions(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewlyMentionedUsers(t *testing.T) {
+	previous := []Mention{{UserID: "a"}}
+	current := []Mention{{UserID: "a"}, {UserID: "b"}, {UserID: "b"}, {UserID: "c"}}
+	if got, want := NewlyMentionedUsers(previous, current), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NewlyMentionedUsers() = %v, want %v", got, want)
+	}
+}
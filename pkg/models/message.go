@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 )
 
@@ -10,7 +11,10 @@ type Message struct {
 	RoomID    string    `json:"room_id"  gorm:"type:varchar(191)"`
 	Sender    string    `json:"sender_id" gorm:"type:varchar(191)"`
 	Message   string    `json:"message"`
+	Hidden    bool      `json:"-" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at"`
+	// LinkPreview is read from the cache, it is not a column of the messages table
+	LinkPreview *LinkPreview `json:"link_preview,omitempty" gorm:"-"`
 }
 
 func SendMessage(db *sql.DB, id string, roomID string, senderID string, message string, createdAt time.Time) {
@@ -20,13 +24,44 @@ func SendMessage(db *sql.DB, id string, roomID string, senderID string, message
 	VALUES (?, ?, ?, ?, ?)`, id, roomID, senderID, message, createdAt)
 }
 
+func GetMessageByID(db *sql.DB, id string) (Message, error) {
+
+	//get the message by id from the database
+	var message Message
+	if err := db.QueryRow(`SELECT id, room_id, sender, message, hidden, created_at
+							FROM messages
+							WHERE id = ?`, id).
+		Scan(
+			&message.ID,
+			&message.RoomID,
+			&message.Sender,
+			&message.Message,
+			&message.Hidden,
+			&message.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return message, errors.New("Message not found in the database")
+		}
+		return message, err
+	}
+	return message, nil
+}
+
+func SetMessageHidden(db *sql.DB, id string, hidden bool) error {
+
+	//hide the message (moderation) or make it visible again
+	_, err := db.Exec(`UPDATE messages
+						SET hidden = ?
+						WHERE id = ?`, hidden, id)
+	return err
+}
+
 func GetMessagesByRoomID(db *sql.DB, id string) ([]Message, error) {
 
 	//get the messages by the room id
 	var messages []Message
 	rows, err := db.Query(`SELECT id, room_id, sender, message, created_at
 							FROM messages 
-							WHERE room_id = ? 
+							WHERE room_id = ? AND hidden = false 
 							ORDER BY created_at ASC`, id)
 	if err != nil {
 		return nil, err
@@ -49,5 +84,10 @@ func GetMessagesByRoomID(db *sql.DB, id string) ([]Message, error) {
 	if err = rows.Err(); err != nil {
 		return messages, err
 	}
+
+	//show the previews of the links from the messages
+	for i := range messages {
+		messages[i].LinkPreview = LinkPreviewOf(db, messages[i].Message)
+	}
 	return messages, nil
 }
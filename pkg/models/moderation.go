@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Moderator rows are added directly in the database by the administrators
+type Moderator struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(191);unique"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Suspension struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"type:varchar(191)"`
+	ModeratorID string    `json:"moderator_id" gorm:"type:varchar(191)"`
+	Reason      string    `json:"reason"`
+	EndsAt      time.Time `json:"ends_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func IsModerator(db *sql.DB, userID string) bool {
+
+	//check whether the user is a moderator
+	var count int
+	db.QueryRow(`SELECT COUNT(*)
+					FROM moderators
+					WHERE user_id = ?`, userID).Scan(&count)
+	return count > 0
+}
+
+func SuspendUser(db *sql.DB, userID string, moderatorID string, reason string, endsAt time.Time) (Suspension, error) {
+
+	//save the suspension in the database
+	suspension := Suspension{ID: uuid.New().String(), UserID: userID, ModeratorID: moderatorID, Reason: reason, EndsAt: endsAt}
+	_, err := db.Exec(`INSERT INTO suspensions (id, user_id, moderator_id, reason, ends_at)
+						VALUES (?, ?, ?, ?, ?)`, suspension.ID, userID, moderatorID, reason, endsAt)
+	return suspension, err
+}
+
+func IsSuspended(db *sql.DB, userID string) bool {
+
+	//check whether the user has a suspension that did not end yet
+	var count int
+	db.QueryRow(`SELECT COUNT(*)
+					FROM suspensions
+					WHERE user_id = ? AND ends_at > ?`, userID, time.Now()).Scan(&count)
+	return count > 0
+}
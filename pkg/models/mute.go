@@ -0,0 +1,154 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Mute struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserMuteID  string    `json:"user_mute_id"  gorm:"type:varchar(191)"`
+	UserMutedID string    `json:"user_muted_id"  gorm:"type:varchar(191)"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type MuteAPI struct {
+	MuteID                string `json:"mute_id"`
+	UserMutedID           string `json:"user_muted_id"`
+	UserMutedFirstName    string `json:"user_muted_firstname"`
+	UserMutedLastName     string `json:"user_muted_lastname"`
+	UserMutedProfileImage string `json:"user_muted_profile_image"`
+}
+
+func GetMutedUsers(db *sql.DB, id string) ([]MuteAPI, error) {
+
+	//get all muted users
+	var mutes []MuteAPI
+	rows, err := db.Query(`SELECT mutes.id as MuteID, user_muted_id, users.first_name, users.last_name, users.user_photo_url
+							FROM mutes
+							LEFT JOIN users ON users.id = mutes.user_muted_id
+							WHERE user_mute_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the mutes slice
+	for rows.Next() {
+		var mute MuteAPI
+		if err := rows.
+			Scan(&mute.MuteID,
+				&mute.UserMutedID,
+				&mute.UserMutedFirstName,
+				&mute.UserMutedLastName,
+				&mute.UserMutedProfileImage); err != nil {
+			return mutes, err
+		}
+		mutes = append(mutes, mute)
+	}
+	if err = rows.Err(); err != nil {
+		return mutes, err
+	}
+	return mutes, nil
+}
+
+func GetMutedUsersID(db *sql.DB, loggedInUserID string) ([]string, error) {
+
+	//get the ids of the users muted by the logged in user (muting is one-sided)
+	var muted []string
+	rows, err := db.Query(`SELECT user_muted_id
+							FROM mutes
+							WHERE user_mute_id = ?`, loggedInUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows from the result and fullfill muted slice
+	for rows.Next() {
+		var mute string
+		if err := rows.
+			Scan(&mute); err != nil {
+			return muted, err
+		}
+		muted = append(muted, mute)
+	}
+	if err = rows.Err(); err != nil {
+		return muted, err
+	}
+	return muted, nil
+}
+
+func GetMutingUsersID(db *sql.DB, userID string) ([]string, error) {
+
+	//get the ids of the users who muted the user
+	var muting []string
+	rows, err := db.Query(`SELECT user_mute_id
+							FROM mutes
+							WHERE user_muted_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows from the result and fullfill muting slice
+	for rows.Next() {
+		var mute string
+		if err := rows.
+			Scan(&mute); err != nil {
+			return muting, err
+		}
+		muting = append(muting, mute)
+	}
+	if err = rows.Err(); err != nil {
+		return muting, err
+	}
+	return muting, nil
+}
+
+func CheckMuteStatus(db *sql.DB, loggedInUserID string, userID string) bool {
+
+	//check whether the logged in user muted the user
+	mute, _ := GetMute(db, loggedInUserID, userID)
+	return mute.ID != ""
+}
+
+func MuteUser(db *sql.DB, id string, userID string, mutedUserID string) error {
+
+	//mute the user
+	_, err := db.Exec(`INSERT INTO mutes (id, user_mute_id, user_muted_id)
+						VALUES (?, ?, ?)`, id, userID, mutedUserID)
+	return err
+}
+
+func GetMute(db *sql.DB, loggedInUserID string, userID string) (Mute, error) {
+
+	//get the info about the mute
+	var mute Mute
+	if err := db.QueryRow(`SELECT id, user_mute_id, user_muted_id, created_at, updated_at
+							FROM mutes
+							WHERE user_mute_id = ? AND user_muted_id = ?`, loggedInUserID, userID).
+		Scan(
+			&mute.ID,
+			&mute.UserMuteID,
+			&mute.UserMutedID,
+			&mute.CreatedAt,
+			&mute.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return mute, nil
+		}
+
+		return mute, err
+	}
+	return mute, nil
+}
+
+func Unmute(db *sql.DB, id string) error {
+
+	//delete the mute (unmute the person)
+	_, err := db.Exec(`DELETE
+						FROM mutes
+						WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const (
+	testMuterID = "40469e94-124c-4f17-96f7-f5283df7d707"
+	testMutedID = "50569e94-124c-4f17-96f7-f5283df7d505"
+)
+
+func TestSaveNotificationMuted(t *testing.T) {
+	tests := []struct {
+		name       string
+		muted      bool
+		wantInsert bool
+	}{
+		{name: "Muted sender is dropped", muted: true, wantInsert: false},
+		{name: "Sender that is not muted is saved", muted: false, wantInsert: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+
+			mock.ExpectQuery("FROM blocks").WithArgs(testMutedID, testMuterID, testMuterID, testMutedID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			rows := sqlmock.NewRows([]string{"id", "user_mute_id", "user_muted_id", "created_at", "updated_at"})
+			if tt.muted {
+				rows.AddRow("mute-id", testMuterID, testMutedID, time.Now(), time.Now())
+			}
+			mock.ExpectQuery("FROM mutes").WithArgs(testMuterID, testMutedID).WillReturnRows(rows)
+			if tt.wantInsert {
+				mock.ExpectExec("INSERT INTO notifications").
+					WithArgs(sqlmock.AnyArg(), testMuterID, testMutedID, "/post/1", "liked your post", "unread").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			SaveNotification(sqlDB, testMuterID, testMutedID, "liked your post", "/post/1")
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGetTimelinePostsSkipsMutedAuthors(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	since := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}
+
+	//both the timeline and the celebrity posts leave out the authors muted by the viewer
+	mock.ExpectQuery(`FROM timelines .* timelines.author_id NOT IN \(SELECT user_muted_id FROM mutes WHERE user_mute_id = \?\)`).
+		WithArgs(testMuterID, since, testMuterID, 20).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("post-id", "", "Friend post", "friend-id", "", false, PostStatusPublished, nil, nil, nil, since, since))
+	mock.ExpectQuery(`INNER JOIN celebrities .* posts.user_id NOT IN \(SELECT user_muted_id FROM mutes WHERE user_mute_id = \?\)`).
+		WithArgs(testMuterID, testMuterID, since, testMuterID, 20).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	posts, err := GetTimelinePosts(sqlDB, testMuterID, since, 20)
+	if err != nil || len(posts) != 1 || posts[0].ID != "post-id" {
+		t.Errorf("GetTimelinePosts() = %v, %v", posts, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckRestrictStatus(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	mock.ExpectQuery("FROM restricts").WithArgs(testMuterID, testMutedID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_restrict_id", "user_restricted_id", "created_at", "updated_at"}).
+			AddRow("restrict-id", testMuterID, testMutedID, time.Now(), time.Now()))
+	mock.ExpectQuery("FROM restricts").WithArgs(testMutedID, testMuterID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_restrict_id", "user_restricted_id", "created_at", "updated_at"}))
+
+	//restricting is one-sided
+	if !CheckRestrictStatus(sqlDB, testMuterID, testMutedID) {
+		t.Error("the restricted user should be found")
+	}
+	if CheckRestrictStatus(sqlDB, testMutedID, testMuterID) {
+		t.Error("the restriction should not work the other way")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
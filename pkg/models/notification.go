@@ -37,7 +37,28 @@ func SaveNotification(db *sql.DB, userID string, senderID string, message string
 		return
 	}
 
+	//if the users blocked each other or the user muted the sender, do not save the notification
+	if !CanInteract(db, senderID, userID, ActionNotify) {
+		return
+	}
+
 	//save the notification in the database
+	insertNotification(db, userID, senderID, message, url)
+}
+
+// SaveSystemNotification saves the notification sent by the moderators, the blocks and the mutes of the user do not apply to it
+func SaveSystemNotification(db *sql.DB, userID string, senderID string, message string, url string) {
+
+	//the moderators do not notify themselves
+	if userID == senderID {
+		return
+	}
+
+	//save the notification in the database
+	insertNotification(db, userID, senderID, message, url)
+}
+
+func insertNotification(db *sql.DB, userID string, senderID string, message string, url string) {
 	db.Exec(`INSERT INTO notifications (id, user_id, sender_id, url, message, state) 
 				VALUES (?, ?, ?, ?, ?, ?)`, uuid.New().String(), userID, senderID, url, message, "unread")
 }
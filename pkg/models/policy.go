@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// actions that one user (actor) can take towards another user (target)
+const (
+	ActionViewProfile   = "view_profile"
+	ActionViewFriends   = "view_friends"
+	ActionViewPost      = "view_post"
+	ActionComment       = "comment"
+	ActionLike          = "like"
+	ActionFriendRequest = "friend_request"
+	ActionMessage       = "message"
+	ActionNotify        = "notify"
+)
+
+// CanInteract is the single place where blocks, privacy settings and mutes are checked
+// before the actor is allowed to read or write anything that belongs to the target.
+func CanInteract(db *sql.DB, actorID string, targetID string, action string) bool {
+
+	//users can always interact with their own content
+	if actorID == targetID {
+		return true
+	}
+
+	//nothing is allowed when one of the users blocked the other one
+	blocked, err := CheckBlockStatus(db, actorID, targetID)
+	if err != nil || blocked {
+		return false
+	}
+
+	//check the rules specific for the action
+	switch action {
+	case ActionViewPost, ActionComment, ActionLike:
+		return EnablePostView(actorID, targetID)
+	case ActionViewFriends:
+		return CanViewFriendList(db, actorID, targetID)
+	case ActionFriendRequest:
+		return CanSendFriendRequest(db, actorID, targetID)
+	case ActionMessage:
+		return areFriends(db, actorID, targetID)
+	case ActionNotify:
+		return !CheckMuteStatus(db, targetID, actorID)
+	default:
+		return true
+	}
+}
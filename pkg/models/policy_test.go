@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSaveNotificationBlocked(t *testing.T) {
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	userID := "40469e94-124c-4f17-96f7-f5283df7d707"
+	senderID := "50569e94-124c-4f17-96f7-f5283df7d505"
+
+	//the block is found, so the notification must not be inserted
+	mock.ExpectQuery("FROM blocks").WithArgs(senderID, userID, userID, senderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("70769e94-124c-4f17-96f7-f5283df7d808"))
+
+	SaveNotification(sqlDB, userID, senderID, "liked your post", "/post/1")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("SaveNotification() %v", err)
+	}
+}
+
+func TestCanInteractBlocked(t *testing.T) {
+	actions := []string{ActionViewProfile, ActionViewFriends, ActionViewPost, ActionComment, ActionLike, ActionFriendRequest, ActionMessage, ActionNotify}
+
+	for _, action := range actions {
+		t.Run(action, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+
+			mock.ExpectQuery("FROM blocks").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("70769e94-124c-4f17-96f7-f5283df7d808"))
+
+			if CanInteract(sqlDB, "actor", "target", action) {
+				t.Errorf("CanInteract(%s) = true, want false", action)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 6
+)
+
+// Poll is attached to the post, the post can have only one poll
+type Poll struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	PostID         string     `json:"post_id" gorm:"type:varchar(191);uniqueIndex"`
+	MultipleChoice bool       `json:"multiple_choice" gorm:"default:false"`
+	HideResults    bool       `json:"hide_results" gorm:"default:false"`
+	EndsAt         *time.Time `json:"ends_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type PollOption struct {
+	ID       string `json:"id" gorm:"primaryKey"`
+	PollID   string `json:"-" gorm:"type:varchar(191);index"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+}
+
+// PollVote is the option chosen by the user, in the single choice poll the user has only one vote
+type PollVote struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	PollID    string    `json:"poll_id" gorm:"type:varchar(191);uniqueIndex:idx_poll_vote_user_option"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(191);uniqueIndex:idx_poll_vote_user_option"`
+	OptionID  string    `json:"option_id" gorm:"type:varchar(191);uniqueIndex:idx_poll_vote_user_option"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PollOptionAPI struct {
+	PollOption
+	Votes *int `json:"votes,omitempty"`
+}
+
+// PollAPI is the poll shown with the post, the vote counts are left out while the results are hidden from the viewer
+type PollAPI struct {
+	Poll
+	Options       []PollOptionAPI `json:"options"`
+	Voters        *int            `json:"voters,omitempty"`
+	MyVotes       []string        `json:"my_votes"`
+	Closed        bool            `json:"closed"`
+	ResultsHidden bool            `json:"results_hidden"`
+}
+
+func (poll Poll) IsClosed(now time.Time) bool {
+	return poll.EndsAt != nil && !now.Before(*poll.EndsAt)
+}
+
+// createPoll saves the poll with its options, it runs in the transaction that creates the post
+func createPoll(db execer, poll Poll, options []string) (Poll, error) {
+
+	//save the poll in the database
+	poll.ID = uuid.New().String()
+	if _, err := db.Exec(`INSERT INTO polls (id, post_id, multiple_choice, hide_results, ends_at)
+							VALUES (?, ?, ?, ?, ?)`, poll.ID, poll.PostID, poll.MultipleChoice, poll.HideResults, poll.EndsAt); err != nil {
+		return poll, err
+	}
+
+	//save the options in the order they were given
+	for i, text := range options {
+		if _, err := db.Exec(`INSERT INTO poll_options (id, poll_id, position, text)
+								VALUES (?, ?, ?, ?)`, uuid.New().String(), poll.ID, i, text); err != nil {
+			return poll, err
+		}
+	}
+	return poll, nil
+}
+
+func GetPollByPostID(db *sql.DB, postID string) (Poll, error) {
+
+	//get the poll of the post from the database
+	var poll Poll
+	if err := db.QueryRow(`SELECT id, post_id, multiple_choice, hide_results, ends_at, created_at
+							FROM polls
+							WHERE post_id = ?`, postID).
+		Scan(
+			&poll.ID,
+			&poll.PostID,
+			&poll.MultipleChoice,
+			&poll.HideResults,
+			&poll.EndsAt,
+			&poll.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return poll, errors.New("Poll not found in the database")
+		}
+		return poll, err
+	}
+	return poll, nil
+}
+
+func GetPollOptions(db *sql.DB, pollID string) ([]PollOption, error) {
+
+	//get the options of the poll in their order
+	var options []PollOption
+	rows, err := db.Query(`SELECT id, poll_id, position, text
+							FROM poll_options
+							WHERE poll_id = ?
+							ORDER BY position ASC`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the options slice
+	for rows.Next() {
+		var option PollOption
+		if err := rows.
+			Scan(&option.ID,
+				&option.PollID,
+				&option.Position,
+				&option.Text); err != nil {
+			return options, err
+		}
+		options = append(options, option)
+	}
+	if err = rows.Err(); err != nil {
+		return options, err
+	}
+	return options, nil
+}
+
+func GetPollVoteCounts(db *sql.DB, pollID string) (map[string]int, int, error) {
+
+	//count the votes of every option
+	counts := make(map[string]int)
+	rows, err := db.Query(`SELECT option_id, COUNT(*)
+							FROM poll_votes
+							WHERE poll_id = ?
+							GROUP BY option_id`, pollID)
+	if err != nil {
+		return counts, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var optionID string
+		var count int
+		if err := rows.Scan(&optionID, &count); err != nil {
+			return counts, 0, err
+		}
+		counts[optionID] = count
+	}
+	if err = rows.Err(); err != nil {
+		return counts, 0, err
+	}
+
+	//count the users that voted, in the multiple choice poll one user can vote for more options
+	var voters int
+	err = db.QueryRow(`SELECT COUNT(DISTINCT user_id)
+						FROM poll_votes
+						WHERE poll_id = ?`, pollID).Scan(&voters)
+	return counts, voters, err
+}
+
+func GetUserPollVotes(db *sql.DB, pollID string, userID string) []string {
+
+	//get the options chosen by the user
+	var optionIDs []string
+	rows, err := db.Query(`SELECT option_id
+							FROM poll_votes
+							WHERE poll_id = ? AND user_id = ?`, pollID, userID)
+	if err != nil {
+		return optionIDs
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var optionID string
+		if err := rows.Scan(&optionID); err != nil {
+			return optionIDs
+		}
+		optionIDs = append(optionIDs, optionID)
+	}
+	return optionIDs
+}
+
+// ValidPollVote checks that the chosen options belong to the poll, are not repeated, and that the single choice poll gets one option
+func ValidPollVote(poll Poll, options []PollOption, optionIDs []string) bool {
+	if len(optionIDs) == 0 || (!poll.MultipleChoice && len(optionIDs) > 1) {
+		return false
+	}
+	for i, optionID := range optionIDs {
+		if slices.Contains(optionIDs[:i], optionID) {
+			return false
+		}
+		if slices.IndexFunc(options, func(option PollOption) bool { return option.ID == optionID }) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+func VotePoll(db *sql.DB, pollID string, userID string, optionIDs []string) error {
+
+	//the new vote replaces the previous one, so both happen in one transaction
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE
+							FROM poll_votes
+							WHERE poll_id = ? AND user_id = ?`, pollID, userID); err != nil {
+		return err
+	}
+	for _, optionID := range optionIDs {
+		if _, err := tx.Exec(`INSERT INTO poll_votes (id, poll_id, user_id, option_id)
+								VALUES (?, ?, ?, ?)`, uuid.New().String(), pollID, userID, optionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func DeletePoll(db *sql.DB, postID string) error {
+
+	//delete the votes and the options of the poll of the post
+	if _, err := db.Exec(`DELETE
+							FROM poll_votes
+							WHERE poll_id IN (SELECT id FROM polls WHERE post_id = ?)`, postID); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DELETE
+							FROM poll_options
+							WHERE poll_id IN (SELECT id FROM polls WHERE post_id = ?)`, postID); err != nil {
+		return err
+	}
+
+	//delete the poll in the database
+	_, err := db.Exec(`DELETE
+						FROM polls
+						WHERE post_id = ?`, postID)
+	return err
+}
+
+// PollInfo returns the poll of the post as the viewer sees it, or nil when the post has no poll
+func PollInfo(db *sql.DB, post Post, viewerID string, now time.Time) *PollAPI {
+	poll, err := GetPollByPostID(db, post.ID)
+	if err != nil {
+		return nil
+	}
+	options, err := GetPollOptions(db, poll.ID)
+	if err != nil {
+		return nil
+	}
+
+	pollInfo := PollAPI{
+		Poll:    poll,
+		MyVotes: GetUserPollVotes(db, poll.ID, viewerID),
+		Closed:  poll.IsClosed(now),
+	}
+
+	//the author can choose to show the results only to the users that voted, until the poll closes
+	pollInfo.ResultsHidden = poll.HideResults && !pollInfo.Closed && len(pollInfo.MyVotes) == 0 && post.UserID != viewerID
+
+	var counts map[string]int
+	if !pollInfo.ResultsHidden {
+		var voters int
+		counts, voters, _ = GetPollVoteCounts(db, poll.ID)
+		pollInfo.Voters = &voters
+	}
+	for _, option := range options {
+		optionInfo := PollOptionAPI{PollOption: option}
+		if !pollInfo.ResultsHidden {
+			votes := counts[option.ID]
+			optionInfo.Votes = &votes
+		}
+		pollInfo.Options = append(pollInfo.Options, optionInfo)
+	}
+	return &pollInfo
+}
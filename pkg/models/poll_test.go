@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidPollVote(t *testing.T) {
+	options := []PollOption{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	tests := []struct {
+		name           string
+		multipleChoice bool
+		optionIDs      []string
+		want           bool
+	}{
+		{name: "One option in a single choice poll", optionIDs: []string{"a"}, want: true},
+		{name: "No options", optionIDs: nil, want: false},
+		{name: "More options in a single choice poll", optionIDs: []string{"a", "b"}, want: false},
+		{name: "More options in a multiple choice poll", multipleChoice: true, optionIDs: []string{"a", "c"}, want: true},
+		{name: "Repeated option", multipleChoice: true, optionIDs: []string{"a", "a"}, want: false},
+		{name: "Option of another poll", optionIDs: []string{"d"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := Poll{MultipleChoice: tt.multipleChoice}
+			if got := ValidPollVote(poll, options, tt.optionIDs); got != tt.want {
+				t.Errorf("ValidPollVote(%v) = %v, want %v", tt.optionIDs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollIsClosed(t *testing.T) {
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	endsAt := now.Add(time.Hour)
+
+	if (Poll{}).IsClosed(now) {
+		t.Error("IsClosed() without an end time = true, want false")
+	}
+	if (Poll{EndsAt: &endsAt}).IsClosed(now) {
+		t.Error("IsClosed() before the end = true, want false")
+	}
+	if !(Poll{EndsAt: &endsAt}).IsClosed(endsAt) {
+		t.Error("IsClosed() at the end = false, want true")
+	}
+}
@@ -6,26 +6,58 @@ import (
 	"time"
 
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
-	"github.com/jmoiron/sqlx"
+	"github.com/dika-bosnjak/social-media-app/pkg/search"
 )
 
+// states of the post, only the published posts are shown to other users
+const (
+	PostStatusPublished = "published"
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+)
+
+// MaxPinnedPosts is the number of the posts that the author can pin to the top of the profile
+const MaxPinnedPosts = 3
+
 type Post struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	Photo     string    `json:"photo_url"`
-	Text      string    `json:"text"`
-	UserID    string    `json:"user_id" gorm:"type:varchar(191)"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string     `json:"id" gorm:"primaryKey"`
+	Photo      string     `json:"photo_url"`
+	Text       string     `json:"text"`
+	UserID     string     `json:"user_id" gorm:"type:varchar(191)"`
+	RepostOfID string     `json:"repost_of_id" gorm:"type:varchar(191);index"`
+	Hidden     bool       `json:"hidden" gorm:"default:false"`
+	Status     string     `json:"status" gorm:"type:varchar(191);default:published;index"`
+	PublishAt  *time.Time `json:"publish_at"`
+	EditedAt   *time.Time `json:"edited_at"`
+	PinnedAt   *time.Time `json:"pinned_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 type PostAPI struct {
-	Post         Post         `json:"post"`
-	User         Author       `json:"user"`
-	Likes        int          `json:"likes"`
-	CommentCount int          `json:"comment_count"`
-	Comments     []CommentAPI `json:"comments"`
-	IsAuthor     bool         `json:"is_author"`
-	AlreadyLiked bool         `json:"already_liked"`
+	Post         Post           `json:"post"`
+	User         Author         `json:"user"`
+	Likes        int            `json:"likes"`
+	Reactions    map[string]int `json:"reactions"`
+	MyReaction   string         `json:"my_reaction"`
+	Mentions     []Mention      `json:"mentions"`
+	ShareCount   int            `json:"share_count"`
+	Saved        bool           `json:"saved"`
+	Pinned       bool           `json:"pinned"`
+	Shared       *SharedPost    `json:"shared,omitempty"`
+	Poll         *PollAPI       `json:"poll,omitempty"`
+	LinkPreview  *LinkPreview   `json:"link_preview,omitempty"`
+	CommentCount int            `json:"comment_count"`
+	Comments     []CommentAPI   `json:"comments"`
+	IsAuthor     bool           `json:"is_author"`
+	AlreadyLiked bool           `json:"already_liked"`
+}
+
+// SharedPost is the original post shown inside the repost, it is unavailable when the original is deleted or the viewer can not see it
+type SharedPost struct {
+	Post        *Post   `json:"post,omitempty"`
+	User        *Author `json:"user,omitempty"`
+	Unavailable bool    `json:"unavailable"`
 }
 
 type Author struct {
@@ -34,29 +66,109 @@ type Author struct {
 	UserPhotoURL string `json:"user_photo_url"`
 }
 
-func CreatePost(db *sql.DB, post Post) (Post, error) {
+// postColumns are the columns read by scanPost, in the same order
+const postColumns = `posts.id, posts.photo, posts.text, posts.user_id, posts.repost_of_id, posts.hidden, posts.status, posts.publish_at, posts.edited_at, posts.pinned_at, posts.created_at, posts.updated_at`
+
+// postVisibleCondition narrows a query on posts (joined with users on the author) to the live posts that CanViewPost allows,
+// the arguments are given by postVisibleArgs
+const postVisibleCondition = `posts.hidden = false AND posts.status = 'published' AND (posts.user_id = ? OR users.profile_type = 'public' OR EXISTS (SELECT 1 FROM friendships
+									WHERE friendships.status = 'accepted'
+										AND ((friendships.user_sent_req_id = ? AND friendships.user_got_req_id = posts.user_id)
+											OR (friendships.user_got_req_id = ? AND friendships.user_sent_req_id = posts.user_id))))
+								AND posts.user_id NOT IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+									UNION
+									SELECT user_block_id FROM blocks WHERE user_blocked_id = ?)`
+
+// postVisibleArgs returns the arguments of postVisibleCondition
+func postVisibleArgs(viewerID string) []interface{} {
+	return []interface{}{viewerID, viewerID, viewerID, viewerID, viewerID}
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPost(row rowScanner) (Post, error) {
+	var post Post
+	err := row.Scan(
+		&post.ID,
+		&post.Photo,
+		&post.Text,
+		&post.UserID,
+		&post.RepostOfID,
+		&post.Hidden,
+		&post.Status,
+		&post.PublishAt,
+		&post.EditedAt,
+		&post.PinnedAt,
+		&post.CreatedAt,
+		&post.UpdatedAt)
+	return post, err
+}
+
+// IsLive reports whether the post is published and not hidden, other users can interact only with the live posts
+func (post Post) IsLive() bool {
+	return !post.Hidden && post.Status == PostStatusPublished
+}
 
-	//crate a new post in the database
-	_, err := db.Exec(`INSERT INTO posts (id, photo, text, user_id) 
-						VALUES (?, ?, ?, ?)`, post.ID, post.Photo, post.Text, post.UserID)
+func CreatePost(db *sql.DB, post Post) (Post, error) {
+	post, _, err := CreatePostWithPoll(db, post, nil, nil)
 	return post, err
+}
+
+// CreatePostWithPoll saves the post, its hashtags and its poll (when it is given) in one transaction
+func CreatePostWithPoll(db *sql.DB, post Post, poll *Poll, options []string) (Post, Poll, error) {
+
+	//crate a new post in the database, the post is published right away unless it is a draft or scheduled
+	if post.Status == "" {
+		post.Status = PostStatusPublished
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return post, Poll{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO posts (id, photo, text, user_id, repost_of_id, hidden, status, publish_at) 
+							VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, post.ID, post.Photo, post.Text, post.UserID, post.RepostOfID, post.Hidden, post.Status, post.PublishAt); err != nil {
+		return post, Poll{}, err
+	}
 
+	//index the hashtags of the post
+	if err := setPostHashtags(tx, post.ID, post.Text); err != nil {
+		return post, Poll{}, err
+	}
+
+	//save the poll of the post
+	var created Poll
+	if poll != nil {
+		poll.PostID = post.ID
+		if created, err = createPoll(tx, *poll, options); err != nil {
+			return post, created, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return post, created, err
+	}
+
+	//index the post for the search
+	indexPost(post)
+	return post, created, nil
 }
 
 func GetPostByID(db *sql.DB, id string) (Post, error) {
 
 	//get the post by id from the database
-	var post Post
-	if err := db.QueryRow(`SELECT * 
-							FROM posts 
-							WHERE id = ?`, id).
-		Scan(
-			&post.ID,
-			&post.Photo,
-			&post.Text,
-			&post.UserID,
-			&post.CreatedAt,
-			&post.UpdatedAt); err != nil {
+	post, err := scanPost(db.QueryRow(`SELECT `+postColumns+` 
+										FROM posts 
+										WHERE id = ?`, id))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return post, errors.New("Post not found in the database")
 		}
@@ -86,35 +198,84 @@ func GetPostAuthor(db *sql.DB, id string) (Author, error) {
 
 func UpdatePost(db *sql.DB, post Post, text string, photo string) (Post, error) {
 
+	//nothing is changed, so there is no new revision
+	if text == post.Text && photo == post.Photo {
+		return post, nil
+	}
+
+	//keep the previous version of the published post in the edit history, drafts have no history
+	var editedAt *time.Time
+	if post.Status == PostStatusPublished {
+		now := time.Now()
+		editedAt = &now
+		if err := CreatePostRevision(db, post, now); err != nil {
+			return post, err
+		}
+	}
+
 	//update the post in the database
 	sqlStatement := `UPDATE posts 
-						SET text = ?, photo = ? 
+						SET text = ?, photo = ?, edited_at = COALESCE(?, edited_at) 
 						WHERE id = ?`
-	_, err := db.Exec(sqlStatement, text, photo, post.ID)
+	_, err := db.Exec(sqlStatement, text, photo, editedAt, post.ID)
 	if err != nil {
 		return post, err
 	}
+
+	//index the hashtags of the new text
+	if err := SetPostHashtags(db, post.ID, text); err != nil {
+		return post, err
+	}
 	post, _ = GetPostByID(initializers.DB, post.ID)
+	indexPost(post)
 	return post, nil
 }
 
 func DeletePost(db *sql.DB, id string) error {
 
-	//delete the post in the database
+	//delete the edit history, the reactions, the mentions, the bookmarks, the poll and the timeline entries of the post
+	if err := DeletePostRevisions(db, id); err != nil {
+		return err
+	}
+	if err := DeleteReactions(db, ReactionTargetPost, id); err != nil {
+		return err
+	}
+	if err := DeleteMentions(db, MentionTargetPost, id); err != nil {
+		return err
+	}
+	if err := DeleteBookmarksOfPost(db, id); err != nil {
+		return err
+	}
+	if err := DeletePoll(db, id); err != nil {
+		return err
+	}
+	if err := RemovePostFromTimelines(db, id); err != nil {
+		return err
+	}
+
+	//remove the post from the hashtag index
+	if err := DeletePostHashtags(db, id); err != nil {
+		return err
+	}
+
+	//delete the post in the database and in the search index
 	_, err := db.Exec(`DELETE 
 						FROM posts 
 						WHERE id = ?`, id)
+	if err == nil {
+		initializers.SearchIndex.Remove(search.KindPost, id)
+	}
 	return err
 }
 
 func GetPostsByAuthor(db *sql.DB, id string) ([]Post, error) {
 
-	//get all posts from the author(one user)
+	//get all posts from the author(one user), the pinned posts first
 	var posts []Post
-	rows, err := db.Query(`SELECT id, photo, text, user_id, created_at, updated_at 
+	rows, err := db.Query(`SELECT `+postColumns+` 
 							FROM posts 
-							WHERE user_id = ? 
-							ORDER BY created_at DESC`, id)
+							WHERE user_id = ? AND hidden = false AND status = 'published' 
+							ORDER BY pinned_at IS NULL, pinned_at DESC, created_at DESC`, id)
 	if err != nil {
 		return nil, err
 	}
@@ -122,14 +283,8 @@ func GetPostsByAuthor(db *sql.DB, id string) ([]Post, error) {
 
 	//loop through the rows of the result and fullfill posts
 	for rows.Next() {
-		var post Post
-		if err := rows.
-			Scan(&post.ID,
-				&post.Photo,
-				&post.Text,
-				&post.UserID,
-				&post.CreatedAt,
-				&post.UpdatedAt); err != nil {
+		post, err := scanPost(rows)
+		if err != nil {
 			return posts, err
 		}
 		posts = append(posts, post)
@@ -141,15 +296,14 @@ func GetPostsByAuthor(db *sql.DB, id string) ([]Post, error) {
 
 }
 
-func GetPostsByAuthors(db *sql.DB, ids []string) ([]Post, error) {
+func GetDraftPosts(db *sql.DB, userID string) ([]Post, error) {
 
-	//get posts of all authors
+	//get the drafts and the scheduled posts of the user, the newest first
 	var posts []Post
-	query, args, _ := sqlx.In(`SELECT id, photo, text, user_id, created_at, updated_at 
-								FROM posts 
-								WHERE user_id IN (?) 
-								ORDER BY created_at DESC`, ids)
-	rows, err := db.Query(query, args...)
+	rows, err := db.Query(`SELECT `+postColumns+` 
+							FROM posts 
+							WHERE user_id = ? AND status IN (?, ?) 
+							ORDER BY created_at DESC`, userID, PostStatusDraft, PostStatusScheduled)
 	if err != nil {
 		return nil, err
 	}
@@ -157,14 +311,8 @@ func GetPostsByAuthors(db *sql.DB, ids []string) ([]Post, error) {
 
 	//loop through the rows of the result and fullfill the posts
 	for rows.Next() {
-		var post Post
-		if err := rows.
-			Scan(&post.ID,
-				&post.Photo,
-				&post.Text,
-				&post.UserID,
-				&post.CreatedAt,
-				&post.UpdatedAt); err != nil {
+		post, err := scanPost(rows)
+		if err != nil {
 			return posts, err
 		}
 		posts = append(posts, post)
@@ -172,17 +320,85 @@ func GetPostsByAuthors(db *sql.DB, ids []string) ([]Post, error) {
 	if err = rows.Err(); err != nil {
 		return posts, err
 	}
+	return posts, nil
+}
+
+func GetDueScheduledPosts(db *sql.DB, now time.Time) ([]Post, error) {
+
+	//get the scheduled posts whose time has come
+	var posts []Post
+	rows, err := db.Query(`SELECT `+postColumns+` 
+							FROM posts 
+							WHERE status = ? AND publish_at <= ? 
+							ORDER BY publish_at ASC`, PostStatusScheduled, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	//loop through the rows of the result and fullfill the posts
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return posts, err
+		}
+		posts = append(posts, post)
+	}
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
 	return posts, nil
 }
 
+func PublishPost(db *sql.DB, id string, now time.Time) (bool, error) {
+
+	//publish the draft or the scheduled post, the creation time becomes the time of publishing so that the post is new in the feed
+	result, err := db.Exec(`UPDATE posts 
+							SET status = ?, publish_at = NULL, created_at = ? 
+							WHERE id = ? AND status <> ?`, PostStatusPublished, now, id, PostStatusPublished)
+	if err != nil {
+		return false, err
+	}
+
+	//the post that was already published (by another request or the scheduler) is not published twice
+	affected, err := result.RowsAffected()
+	if affected > 0 {
+		reindexPost(db, id)
+	}
+	return affected > 0, err
+}
+
+func SchedulePost(db *sql.DB, id string, publishAt time.Time) error {
+
+	//schedule the draft to be published at the given time
+	_, err := db.Exec(`UPDATE posts 
+						SET status = ?, publish_at = ? 
+						WHERE id = ? AND status <> ?`, PostStatusScheduled, publishAt, id, PostStatusPublished)
+	return err
+}
+
+func NotifyPublishedPost(db *sql.DB, post Post) {
+
+	//let the friends of the author know about the new post
+	for _, friendID := range GetFriendsIDs(db, post.UserID) {
+		SaveNotification(db, friendID, post.UserID, "published a new post", "/post/"+post.ID)
+	}
+
+	//notify the mentioned users that can see the post
+	for _, userID := range NewlyMentionedUsers(nil, GetMentions(db, MentionTargetPost, post.ID)) {
+		if CanViewPost(db, userID, post) {
+			SaveNotification(db, userID, post.UserID, "mentioned you in a post", "/post/"+post.ID)
+		}
+	}
+}
+
 func GetNumberOfPostsByUserID(db *sql.DB, userID string) int {
 
 	//get the number of the posts written by user id
 	var count int
 	db.QueryRow(`SELECT COUNT(*) 
 					FROM posts 
-					WHERE user_id = ?`, userID).Scan(&count)
+					WHERE user_id = ? AND hidden = false AND status = 'published'`, userID).Scan(&count)
 	return count
 }
 
@@ -208,6 +424,84 @@ func EnablePostView(userID string, ownerID string) bool {
 	return false
 }
 
+func CanViewPost(db *sql.DB, viewerID string, post Post) bool {
+
+	//posts hidden by the moderators, drafts and scheduled posts are visible only to their authors
+	if !post.IsLive() && post.UserID != viewerID {
+		return false
+	}
+
+	return CanInteract(db, viewerID, post.UserID, ActionViewPost)
+}
+
+// FilterVisiblePosts keeps only the posts that the viewer can see
+func FilterVisiblePosts(db *sql.DB, viewerID string, posts []Post) []Post {
+	var visible []Post
+	for _, post := range posts {
+		if CanViewPost(db, viewerID, post) {
+			visible = append(visible, post)
+		}
+	}
+	return visible
+}
+
+func GetNumberOfShares(db *sql.DB, postID string) int {
+
+	//get the number of the visible reposts of the post
+	var count int
+	db.QueryRow(`SELECT COUNT(*) 
+					FROM posts 
+					WHERE repost_of_id = ? AND hidden = false AND status = 'published'`, postID).Scan(&count)
+	return count
+}
+
+// sharedPostInfo gets the original post of the repost, the original is shown only if the viewer could see it on its own,
+// so the repost never widens the audience of the original
+func sharedPostInfo(db *sql.DB, originalID string, viewerID string) *SharedPost {
+	original, err := GetPostByID(db, originalID)
+	if err != nil || !CanViewPost(db, viewerID, original) {
+		return &SharedPost{Unavailable: true}
+	}
+	author, _ := GetPostAuthor(db, original.UserID)
+	return &SharedPost{Post: &original, User: &author}
+}
+
+func GetNumberOfPinnedPosts(db *sql.DB, userID string) int {
+
+	//get the number of the posts pinned to the profile of the user
+	var count int
+	db.QueryRow(`SELECT COUNT(*) 
+					FROM posts 
+					WHERE user_id = ? AND pinned_at IS NOT NULL`, userID).Scan(&count)
+	return count
+}
+
+func SetPostPinned(db *sql.DB, id string, pinned bool) error {
+
+	//pin the post to the top of the profile or unpin it
+	var pinnedAt *time.Time
+	if pinned {
+		now := time.Now()
+		pinnedAt = &now
+	}
+	_, err := db.Exec(`UPDATE posts 
+						SET pinned_at = ? 
+						WHERE id = ?`, pinnedAt, id)
+	return err
+}
+
+func SetPostHidden(db *sql.DB, id string, hidden bool) error {
+
+	//hide the post (moderation) or make it visible again
+	_, err := db.Exec(`UPDATE posts 
+						SET hidden = ? 
+						WHERE id = ?`, hidden, id)
+	if err == nil {
+		reindexPost(db, id)
+	}
+	return err
+}
+
 func PostInfo(post Post, loggedInUserID string) PostAPI {
 	//get the post author
 	author, _ := GetPostAuthor(initializers.DB, post.UserID)
@@ -215,8 +509,12 @@ func PostInfo(post Post, loggedInUserID string) PostAPI {
 	//get the number of likes
 	likeCount := LikeCount(initializers.DB, post.ID)
 
+	//get the number of the reactions of every type and the reaction of the logged in user
+	reactions := ReactionCounts(initializers.DB, ReactionTargetPost, post.ID)
+	myReaction, _ := GetReaction(initializers.DB, ReactionTargetPost, post.ID, loggedInUserID)
+
 	//check whether the logged in user already liked the post
-	alreadyLiked := CheckIfAlreadyLiked(initializers.DB, post.ID, loggedInUserID)
+	alreadyLiked := myReaction.Type == ReactionLike
 
 	//get the number of the comments
 	commentCount := GetNumberOfComments(initializers.DB, post.ID)
@@ -229,10 +527,27 @@ func PostInfo(post Post, loggedInUserID string) PostAPI {
 		Post:         post,
 		User:         author,
 		Likes:        likeCount,
+		Reactions:    reactions,
+		MyReaction:   myReaction.Type,
+		Mentions:     GetMentions(initializers.DB, MentionTargetPost, post.ID),
+		ShareCount:   GetNumberOfShares(initializers.DB, post.ID),
+		Saved:        CheckIfSaved(initializers.DB, post.ID, loggedInUserID),
+		Pinned:       post.PinnedAt != nil,
 		AlreadyLiked: alreadyLiked,
 		CommentCount: commentCount,
 		Comments:     comments,
 	}
 
+	//show the original post inside the repost
+	if post.RepostOfID != "" {
+		postInfo.Shared = sharedPostInfo(initializers.DB, post.RepostOfID, loggedInUserID)
+	}
+
+	//show the preview of the link from the text
+	postInfo.LinkPreview = LinkPreviewOf(initializers.DB, post.Text)
+
+	//show the poll of the post with the results the logged in user can see
+	postInfo.Poll = PollInfo(initializers.DB, post, loggedInUserID, time.Now())
+
 	return postInfo
 }
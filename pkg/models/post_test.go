@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSharedPostInfoDeletedOriginal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM posts").WithArgs("deleted-post").WillReturnError(sql.ErrNoRows)
+
+	shared := sharedPostInfo(db, "deleted-post", "viewer")
+	if !shared.Unavailable || shared.Post != nil {
+		t.Errorf("sharedPostInfo() = %+v, want the unavailable stub", shared)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreatePostWithPollRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//the post is not kept when its poll can not be saved
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO posts").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM post_hashtags").WithArgs("post-id").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO polls").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO poll_options").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	post := Post{ID: "post-id", UserID: "author-id", Text: "Which one?"}
+	if _, _, err := CreatePostWithPoll(db, post, &Poll{}, []string{"First", "Second"}); err == nil {
+		t.Error("CreatePostWithPoll() error = nil, want the error of the options")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
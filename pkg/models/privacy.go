@@ -0,0 +1,194 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// audiences that can be chosen for the profile fields and the friend list
+const (
+	AudienceEveryone = "everyone"
+	AudienceFriends  = "friends"
+	AudienceOnlyMe   = "only_me"
+)
+
+// who is allowed to send a friend request
+const (
+	FriendRequestsEveryone         = "everyone"
+	FriendRequestsFriendsOfFriends = "friends_of_friends"
+)
+
+type PrivacySettings struct {
+	ID                  string    `json:"id" gorm:"primaryKey"`
+	UserID              string    `json:"user_id" gorm:"type:varchar(191);unique"`
+	FriendListAudience  string    `json:"friend_list_audience"`
+	EmailAudience       string    `json:"email_audience"`
+	DescriptionAudience string    `json:"description_audience"`
+	PhotoAudience       string    `json:"photo_audience"`
+	FriendRequests      string    `json:"friend_requests"`
+	Searchable          bool      `json:"searchable"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func DefaultPrivacySettings(userID string) PrivacySettings {
+
+	//settings used for the users that never changed their privacy settings
+	return PrivacySettings{
+		UserID:              userID,
+		FriendListAudience:  AudienceEveryone,
+		EmailAudience:       AudienceOnlyMe,
+		DescriptionAudience: AudienceEveryone,
+		PhotoAudience:       AudienceEveryone,
+		FriendRequests:      FriendRequestsEveryone,
+		Searchable:          true,
+	}
+}
+
+func ValidAudience(audience string) bool {
+	return audience == AudienceEveryone || audience == AudienceFriends || audience == AudienceOnlyMe
+}
+
+func ValidFriendRequestsSetting(setting string) bool {
+	return setting == FriendRequestsEveryone || setting == FriendRequestsFriendsOfFriends
+}
+
+func GetPrivacySettings(db *sql.DB, userID string) (PrivacySettings, error) {
+
+	//get the privacy settings of the user from the database
+	settings := DefaultPrivacySettings(userID)
+	if err := db.QueryRow(`SELECT id, user_id, friend_list_audience, email_audience, description_audience, photo_audience, friend_requests, searchable, created_at, updated_at
+							FROM privacy_settings
+							WHERE user_id = ?`, userID).
+		Scan(
+			&settings.ID,
+			&settings.UserID,
+			&settings.FriendListAudience,
+			&settings.EmailAudience,
+			&settings.DescriptionAudience,
+			&settings.PhotoAudience,
+			&settings.FriendRequests,
+			&settings.Searchable,
+			&settings.CreatedAt,
+			&settings.UpdatedAt); err != nil {
+		//if the user has no settings saved yet, use the default ones
+		if err == sql.ErrNoRows {
+			return DefaultPrivacySettings(userID), nil
+		}
+		return DefaultPrivacySettings(userID), err
+	}
+	return settings, nil
+}
+
+func SavePrivacySettings(db *sql.DB, settings PrivacySettings) (PrivacySettings, error) {
+
+	//insert the settings, or update them if the user already has a record
+	settings.ID = uuid.New().String()
+	_, err := db.Exec(`INSERT INTO privacy_settings (id, user_id, friend_list_audience, email_audience, description_audience, photo_audience, friend_requests, searchable, created_at, updated_at)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+						ON DUPLICATE KEY UPDATE friend_list_audience = VALUES(friend_list_audience), email_audience = VALUES(email_audience), description_audience = VALUES(description_audience),
+							photo_audience = VALUES(photo_audience), friend_requests = VALUES(friend_requests), searchable = VALUES(searchable), updated_at = VALUES(updated_at)`,
+		settings.ID,
+		settings.UserID,
+		settings.FriendListAudience,
+		settings.EmailAudience,
+		settings.DescriptionAudience,
+		settings.PhotoAudience,
+		settings.FriendRequests,
+		settings.Searchable,
+		time.Now(),
+		time.Now())
+	if err != nil {
+		return settings, err
+	}
+
+	return GetPrivacySettings(db, settings.UserID)
+}
+
+func audienceAllows(audience string, isOwner bool, isFriend bool) bool {
+
+	//the owner can always see his own data
+	if isOwner {
+		return true
+	}
+
+	switch audience {
+	case AudienceEveryone:
+		return true
+	case AudienceFriends:
+		return isFriend
+	default:
+		return false
+	}
+}
+
+func areFriends(db *sql.DB, user1ID string, user2ID string) bool {
+
+	//check whether there is an accepted friendship between the users
+	friendship, _ := CheckFriendship(db, user1ID, user2ID)
+	return friendship.ID != "" && friendship.Status == "accepted"
+}
+
+func CanViewField(db *sql.DB, viewerID string, ownerID string, audience string) bool {
+
+	//the friendship is checked only when the audience depends on it
+	isOwner := viewerID == ownerID
+	isFriend := false
+	if !isOwner && audience == AudienceFriends {
+		isFriend = areFriends(db, viewerID, ownerID)
+	}
+	return audienceAllows(audience, isOwner, isFriend)
+}
+
+func CanViewFriendList(db *sql.DB, viewerID string, ownerID string) bool {
+
+	//check the friend list audience of the owner
+	settings, _ := GetPrivacySettings(db, ownerID)
+	return CanViewField(db, viewerID, ownerID, settings.FriendListAudience)
+}
+
+func haveMutualFriend(friends1 []string, friends2 []string) bool {
+
+	//check whether two friend lists share at least one user
+	for _, friendID := range friends1 {
+		if slices.Contains(friends2, friendID) {
+			return true
+		}
+	}
+	return false
+}
+
+func CanSendFriendRequest(db *sql.DB, senderID string, receiverID string) bool {
+
+	//check who the receiver accepts the friend requests from
+	settings, _ := GetPrivacySettings(db, receiverID)
+	if settings.FriendRequests != FriendRequestsFriendsOfFriends {
+		return true
+	}
+
+	//only the friends of friends can send the request
+	return haveMutualFriend(GetFriendsIDs(db, senderID), GetFriendsIDs(db, receiverID))
+}
+
+func ApplyPrivacySettings(db *sql.DB, user User, viewerID string) User {
+
+	//remove the profile fields that the viewer is not allowed to see
+	settings, _ := GetPrivacySettings(db, user.ID)
+	isOwner := viewerID == user.ID
+	isFriend := !isOwner && areFriends(db, viewerID, user.ID)
+
+	if !audienceAllows(settings.EmailAudience, isOwner, isFriend) {
+		user.Email = ""
+	}
+	if !audienceAllows(settings.DescriptionAudience, isOwner, isFriend) {
+		user.ProfileDescription = ""
+	}
+	if !audienceAllows(settings.PhotoAudience, isOwner, isFriend) {
+		user.UserPhotoURL = ""
+	}
+	user.Password = ""
+	return user
+}
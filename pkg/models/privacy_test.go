@@ -0,0 +1,50 @@
+package models
+
+import "testing"
+
+func TestAudienceAllows(t *testing.T) {
+	type args struct {
+		audience string
+		isOwner  bool
+		isFriend bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{name: "Owner can see only_me field", args: args{audience: AudienceOnlyMe, isOwner: true}, want: true},
+		{name: "Stranger can see everyone field", args: args{audience: AudienceEveryone}, want: true},
+		{name: "Stranger can not see friends field", args: args{audience: AudienceFriends}, want: false},
+		{name: "Friend can see friends field", args: args{audience: AudienceFriends, isFriend: true}, want: true},
+		{name: "Friend can not see only_me field", args: args{audience: AudienceOnlyMe, isFriend: true}, want: false},
+		{name: "Unknown audience is hidden", args: args{audience: "", isFriend: true}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := audienceAllows(tt.args.audience, tt.args.isOwner, tt.args.isFriend); got != tt.want {
+				t.Errorf("audienceAllows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHaveMutualFriend(t *testing.T) {
+	tests := []struct {
+		name     string
+		friends1 []string
+		friends2 []string
+		want     bool
+	}{
+		{name: "Shared friend", friends1: []string{"a", "b"}, friends2: []string{"c", "b"}, want: true},
+		{name: "No shared friend", friends1: []string{"a"}, friends2: []string{"c"}, want: false},
+		{name: "Empty friend list", friends1: nil, friends2: []string{"c"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := haveMutualFriend(tt.friends1, tt.friends2); got != tt.want {
+				t.Errorf("haveMutualFriend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/exp/slices"
+)
+
+// types of the reactions
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+// ReactionTypes is the fixed set of the reactions that the users can choose from
+var ReactionTypes = []string{ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad, ReactionAngry}
+
+// types of the content that can get reactions
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+)
+
+// Reaction is the reaction of one user on a post or a comment, one user has at most one reaction per target
+type Reaction struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	UserID     string    `json:"user_id" gorm:"type:varchar(191);uniqueIndex:idx_reaction_user_target"`
+	TargetType string    `json:"target_type" gorm:"type:varchar(191);uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+	TargetID   string    `json:"target_id" gorm:"type:varchar(191);uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+	Type       string    `json:"type"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ReactionAPI is the user that reacted, with the name and the avatar like Author
+type ReactionAPI struct {
+	UserID       string    `json:"user_id"`
+	FirstName    string    `json:"first_name"`
+	LastName     string    `json:"last_name"`
+	UserPhotoURL string    `json:"user_photo_url"`
+	Type         string    `json:"type"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func ValidReactionType(reactionType string) bool {
+	return slices.Contains(ReactionTypes, reactionType)
+}
+
+func GetReaction(db *sql.DB, targetType string, targetID string, userID string) (Reaction, error) {
+
+	//get the reaction of the user on the target, the empty reaction is returned if there is none
+	var reaction Reaction
+	if err := db.QueryRow(`SELECT id, user_id, target_type, target_id, type, created_at, updated_at
+							FROM reactions
+							WHERE target_type = ? AND target_id = ? AND user_id = ?`, targetType, targetID, userID).
+		Scan(
+			&reaction.ID,
+			&reaction.UserID,
+			&reaction.TargetType,
+			&reaction.TargetID,
+			&reaction.Type,
+			&reaction.CreatedAt,
+			&reaction.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return reaction, nil
+		}
+		return reaction, err
+	}
+	return reaction, nil
+}
+
+func React(db *sql.DB, userID string, targetType string, targetID string, reactionType string) error {
+
+	//save the reaction, the previous reaction of the user on the same target is replaced
+	_, err := db.Exec(`INSERT INTO reactions (id, user_id, target_type, target_id, type)
+						VALUES (?, ?, ?, ?, ?)
+						ON DUPLICATE KEY UPDATE type = VALUES(type)`, uuid.New().String(), userID, targetType, targetID, reactionType)
+	return err
+}
+
+func RemoveReaction(db *sql.DB, userID string, targetType string, targetID string) error {
+
+	//remove the reaction of the user on the target
+	_, err := db.Exec(`DELETE
+						FROM reactions
+						WHERE user_id = ? AND target_type = ? AND target_id = ?`, userID, targetType, targetID)
+	return err
+}
+
+func DeleteReactions(db *sql.DB, targetType string, targetID string) error {
+
+	//remove all reactions on the deleted target
+	_, err := db.Exec(`DELETE
+						FROM reactions
+						WHERE target_type = ? AND target_id = ?`, targetType, targetID)
+	return err
+}
+
+func ReactionCounts(db *sql.DB, targetType string, targetID string) map[string]int {
+
+	//get the number of the reactions of every type on the target
+	return ReactionCountsByTargets(db, targetType, []string{targetID})[targetID]
+}
+
+func ReactionCountsByTargets(db *sql.DB, targetType string, targetIDs []string) map[string]map[string]int {
+
+	//get the number of the reactions of every type on every target
+	counts := make(map[string]map[string]int)
+	if len(targetIDs) == 0 {
+		return counts
+	}
+	query, args, _ := sqlx.In(`SELECT target_id, type, COUNT(*)
+								FROM reactions
+								WHERE target_type = ? AND target_id IN (?)
+								GROUP BY target_id, type`, targetType, targetIDs)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return counts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID, reactionType string
+		var count int
+		if err := rows.Scan(&targetID, &reactionType, &count); err != nil {
+			return counts
+		}
+		if counts[targetID] == nil {
+			counts[targetID] = make(map[string]int)
+		}
+		counts[targetID][reactionType] = count
+	}
+	return counts
+}
+
+func UserReactionsByTargets(db *sql.DB, targetType string, targetIDs []string, userID string) map[string]string {
+
+	//get the reaction types of the user on every target
+	reactions := make(map[string]string)
+	if len(targetIDs) == 0 {
+		return reactions
+	}
+	query, args, _ := sqlx.In(`SELECT target_id, type
+								FROM reactions
+								WHERE target_type = ? AND target_id IN (?) AND user_id = ?`, targetType, targetIDs, userID)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return reactions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID, reactionType string
+		if err := rows.Scan(&targetID, &reactionType); err != nil {
+			return reactions
+		}
+		reactions[targetID] = reactionType
+	}
+	return reactions
+}
+
+func GetReactionUsers(db *sql.DB, targetType string, targetID string, reactionType string, viewerID string, limit int, offset int) ([]ReactionAPI, error) {
+
+	//get one page of the users that reacted on the target (all types when the type is empty), users blocked in either direction are left out
+	var users []ReactionAPI
+	rows, err := db.Query(`SELECT reactions.user_id, users.first_name, users.last_name, users.user_photo_url, reactions.type, reactions.created_at
+							FROM reactions
+							INNER JOIN users ON users.id = reactions.user_id
+							WHERE reactions.target_type = ? AND reactions.target_id = ? AND (? = '' OR reactions.type = ?)
+								AND NOT EXISTS (SELECT 1 FROM blocks
+												WHERE (blocks.user_block_id = ? AND blocks.user_blocked_id = reactions.user_id)
+													OR (blocks.user_block_id = reactions.user_id AND blocks.user_blocked_id = ?))
+							ORDER BY reactions.created_at DESC
+							LIMIT ? OFFSET ?`, targetType, targetID, reactionType, reactionType, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the users slice
+	for rows.Next() {
+		var user ReactionAPI
+		if err := rows.
+			Scan(&user.UserID,
+				&user.FirstName,
+				&user.LastName,
+				&user.UserPhotoURL,
+				&user.Type,
+				&user.CreatedAt); err != nil {
+			return users, err
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return users, err
+	}
+	return users, nil
+}
+
+func MigrateLikes(db *sql.DB) (int64, error) {
+
+	//the likes are copied and removed from the old likes table together, so the migration runs only once
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	//copy the likes from the old likes table into the reactions
+	result, err := tx.Exec(`INSERT IGNORE INTO reactions (id, user_id, target_type, target_id, type, created_at, updated_at)
+						SELECT UUID(), user_id, ?, post_id, ?, created_at, updated_at
+						FROM likes
+						WHERE like_bool = true`, ReactionTargetPost, ReactionLike)
+	if err != nil {
+		return 0, err
+	}
+	migrated, _ := result.RowsAffected()
+
+	//empty the old likes table, so the reactions removed later are not copied back
+	if _, err := tx.Exec(`DELETE
+							FROM likes`); err != nil {
+		return 0, err
+	}
+	return migrated, tx.Commit()
+}
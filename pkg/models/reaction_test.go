@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMigrateLikesEmptiesOldTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//the likes are copied and the old table is emptied in one transaction, so the next run has nothing to copy
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO reactions").WithArgs(ReactionTargetPost, ReactionLike).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM likes").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	migrated, err := MigrateLikes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 3 {
+		t.Errorf("MigrateLikes() = %d, want 3", migrated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// types of the content that can be reported
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+	ReportTargetMessage = "message"
+)
+
+// states of the report in the moderation queue
+const (
+	ReportStateOpen      = "open"
+	ReportStateActioned  = "actioned"
+	ReportStateDismissed = "dismissed"
+)
+
+// ReportReasonContentFilter is used for the reports filed by the content filter, they have no reporter
+const ReportReasonContentFilter = "content_filter"
+
+// ReportReasons are the categories that the reporter can choose from
+var ReportReasons = []string{"spam", "harassment", "hate_speech", "nudity", "violence", "misinformation", "other"}
+
+type Report struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	ReporterID    string    `json:"reporter_id" gorm:"type:varchar(191)"`
+	TargetType    string    `json:"target_type" gorm:"type:varchar(191);index:idx_report_target"`
+	TargetID      string    `json:"target_id" gorm:"type:varchar(191);index:idx_report_target"`
+	TargetOwnerID string    `json:"target_owner_id" gorm:"type:varchar(191)"`
+	URL           string    `json:"url"`
+	Reason        string    `json:"reason"`
+	Note          string    `json:"note"`
+	State         string    `json:"state"`
+	ModeratorID   string    `json:"moderator_id" gorm:"type:varchar(191)"`
+	Resolution    string    `json:"resolution"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ReportAPI struct {
+	Report            Report `json:"report"`
+	ReporterFirstName string `json:"reporter_first_name"`
+	ReporterLastName  string `json:"reporter_last_name"`
+}
+
+// ReportQueueAPI groups all reports about the same content into one entry of the queue
+type ReportQueueAPI struct {
+	TargetType      string    `json:"target_type"`
+	TargetID        string    `json:"target_id"`
+	TargetOwnerID   string    `json:"target_owner_id"`
+	URL             string    `json:"url"`
+	ReportCount     int       `json:"report_count"`
+	Reasons         []string  `json:"reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+}
+
+func ValidReportTarget(targetType string) bool {
+	return targetType == ReportTargetPost || targetType == ReportTargetComment || targetType == ReportTargetUser || targetType == ReportTargetMessage
+}
+
+func ValidReportReason(reason string) bool {
+	return slices.Contains(ReportReasons, reason)
+}
+
+func CreateReport(db *sql.DB, report Report) (Report, error) {
+
+	//save the report in the moderation queue
+	report.ID = uuid.New().String()
+	report.State = ReportStateOpen
+	_, err := db.Exec(`INSERT INTO reports (id, reporter_id, target_type, target_id, target_owner_id, url, reason, note, state, moderator_id, resolution)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		report.ID,
+		report.ReporterID,
+		report.TargetType,
+		report.TargetID,
+		report.TargetOwnerID,
+		report.URL,
+		report.Reason,
+		report.Note,
+		report.State,
+		"",
+		"")
+	return report, err
+}
+
+func HoldForReview(db *sql.DB, targetType string, targetID string, ownerID string, url string, reason string) error {
+
+	//put the content held by the content filter in the moderation queue
+	_, err := CreateReport(db, Report{
+		TargetType:    targetType,
+		TargetID:      targetID,
+		TargetOwnerID: ownerID,
+		URL:           url,
+		Reason:        ReportReasonContentFilter,
+		Note:          reason,
+	})
+	return err
+}
+
+func IsHeldForReview(db *sql.DB, targetType string, targetID string) bool {
+
+	//check whether the content filter held the content
+	var count int
+	db.QueryRow(`SELECT COUNT(*)
+					FROM reports
+					WHERE target_type = ? AND target_id = ? AND reason = ? AND state = ?`, targetType, targetID, ReportReasonContentFilter, ReportStateOpen).Scan(&count)
+	return count > 0
+}
+
+func CheckOpenReport(db *sql.DB, reporterID string, targetType string, targetID string) bool {
+
+	//check whether the user already has an open report about the content
+	var count int
+	db.QueryRow(`SELECT COUNT(*)
+					FROM reports
+					WHERE reporter_id = ? AND target_type = ? AND target_id = ? AND state = ?`, reporterID, targetType, targetID, ReportStateOpen).Scan(&count)
+	return count > 0
+}
+
+func GetReportQueue(db *sql.DB, state string) ([]ReportQueueAPI, error) {
+
+	//get the reported content, one entry per target, the most reported first
+	var queue []ReportQueueAPI
+	rows, err := db.Query(`SELECT target_type, target_id, MAX(target_owner_id), MAX(url), COUNT(*), GROUP_CONCAT(DISTINCT reason), MIN(created_at), MAX(created_at)
+							FROM reports
+							WHERE state = ?
+							GROUP BY target_type, target_id
+							ORDER BY COUNT(*) DESC, MIN(created_at) ASC`, state)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the queue slice
+	for rows.Next() {
+		var item ReportQueueAPI
+		var reasons string
+		if err := rows.
+			Scan(&item.TargetType,
+				&item.TargetID,
+				&item.TargetOwnerID,
+				&item.URL,
+				&item.ReportCount,
+				&reasons,
+				&item.FirstReportedAt,
+				&item.LastReportedAt); err != nil {
+			return queue, err
+		}
+		item.Reasons = strings.Split(reasons, ",")
+		queue = append(queue, item)
+	}
+	if err = rows.Err(); err != nil {
+		return queue, err
+	}
+	return queue, nil
+}
+
+func GetReportsByTarget(db *sql.DB, targetType string, targetID string) ([]ReportAPI, error) {
+
+	//get all reports about the content, with the reporter names
+	var reports []ReportAPI
+	rows, err := db.Query(`SELECT reports.id, reports.reporter_id, reports.target_type, reports.target_id, reports.target_owner_id, reports.url, reports.reason, reports.note,
+								reports.state, reports.moderator_id, reports.resolution, reports.created_at, reports.updated_at, COALESCE(users.first_name, ''), COALESCE(users.last_name, '')
+							FROM reports
+							LEFT JOIN users ON users.id = reports.reporter_id
+							WHERE reports.target_type = ? AND reports.target_id = ?
+							ORDER BY reports.created_at ASC`, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the reports slice
+	for rows.Next() {
+		var report ReportAPI
+		if err := rows.
+			Scan(&report.Report.ID,
+				&report.Report.ReporterID,
+				&report.Report.TargetType,
+				&report.Report.TargetID,
+				&report.Report.TargetOwnerID,
+				&report.Report.URL,
+				&report.Report.Reason,
+				&report.Report.Note,
+				&report.Report.State,
+				&report.Report.ModeratorID,
+				&report.Report.Resolution,
+				&report.Report.CreatedAt,
+				&report.Report.UpdatedAt,
+				&report.ReporterFirstName,
+				&report.ReporterLastName); err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	if err = rows.Err(); err != nil {
+		return reports, err
+	}
+	return reports, nil
+}
+
+func ResolveReports(db *sql.DB, targetType string, targetID string, state string, moderatorID string, resolution string) ([]string, error) {
+
+	//get the users that reported the content, so that they can be notified (the content filter has no reporter)
+	var reporters []string
+	rows, err := db.Query(`SELECT DISTINCT reporter_id
+							FROM reports
+							WHERE target_type = ? AND target_id = ? AND state = ? AND reporter_id <> ''`, targetType, targetID, ReportStateOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reporter string
+		if err := rows.Scan(&reporter); err != nil {
+			return reporters, err
+		}
+		reporters = append(reporters, reporter)
+	}
+	if err = rows.Err(); err != nil {
+		return reporters, err
+	}
+
+	//close all open reports about the content
+	_, err = db.Exec(`UPDATE reports
+						SET state = ?, moderator_id = ?, resolution = ?
+						WHERE target_type = ? AND target_id = ? AND state = ?`, state, moderatorID, resolution, targetType, targetID, ReportStateOpen)
+	return reporters, err
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Restrict struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	UserRestrictID   string    `json:"user_restrict_id"  gorm:"type:varchar(191)"`
+	UserRestrictedID string    `json:"user_restricted_id"  gorm:"type:varchar(191)"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type RestrictAPI struct {
+	RestrictID                 string `json:"restrict_id"`
+	UserRestrictedID           string `json:"user_restricted_id"`
+	UserRestrictedFirstName    string `json:"user_restricted_firstname"`
+	UserRestrictedLastName     string `json:"user_restricted_lastname"`
+	UserRestrictedProfileImage string `json:"user_restricted_profile_image"`
+}
+
+func GetRestrictedUsers(db *sql.DB, id string) ([]RestrictAPI, error) {
+
+	//get all restricted users
+	var restricts []RestrictAPI
+	rows, err := db.Query(`SELECT restricts.id as RestrictID, user_restricted_id, users.first_name, users.last_name, users.user_photo_url
+							FROM restricts
+							LEFT JOIN users ON users.id = restricts.user_restricted_id
+							WHERE user_restrict_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the restricts slice
+	for rows.Next() {
+		var restrict RestrictAPI
+		if err := rows.
+			Scan(&restrict.RestrictID,
+				&restrict.UserRestrictedID,
+				&restrict.UserRestrictedFirstName,
+				&restrict.UserRestrictedLastName,
+				&restrict.UserRestrictedProfileImage); err != nil {
+			return restricts, err
+		}
+		restricts = append(restricts, restrict)
+	}
+	if err = rows.Err(); err != nil {
+		return restricts, err
+	}
+	return restricts, nil
+}
+
+func CheckRestrictStatus(db *sql.DB, loggedInUserID string, userID string) bool {
+
+	//check whether the logged in user restricted the user
+	restrict, _ := GetRestrict(db, loggedInUserID, userID)
+	return restrict.ID != ""
+}
+
+func RestrictUser(db *sql.DB, id string, userID string, restrictedUserID string) error {
+
+	//restrict the user
+	_, err := db.Exec(`INSERT INTO restricts (id, user_restrict_id, user_restricted_id)
+						VALUES (?, ?, ?)`, id, userID, restrictedUserID)
+	return err
+}
+
+func GetRestrict(db *sql.DB, loggedInUserID string, userID string) (Restrict, error) {
+
+	//get the info about the restriction
+	var restrict Restrict
+	if err := db.QueryRow(`SELECT id, user_restrict_id, user_restricted_id, created_at, updated_at
+							FROM restricts
+							WHERE user_restrict_id = ? AND user_restricted_id = ?`, loggedInUserID, userID).
+		Scan(
+			&restrict.ID,
+			&restrict.UserRestrictID,
+			&restrict.UserRestrictedID,
+			&restrict.CreatedAt,
+			&restrict.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return restrict, nil
+		}
+
+		return restrict, err
+	}
+	return restrict, nil
+}
+
+func Unrestrict(db *sql.DB, id string) error {
+
+	//delete the restriction
+	_, err := db.Exec(`DELETE
+						FROM restricts
+						WHERE id = ?`, id)
+	return err
+}
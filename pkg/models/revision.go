@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/textdiff"
+	"github.com/google/uuid"
+)
+
+// CommentRevision keeps the text that the comment had before an edit
+type CommentRevision struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	CommentID string    `json:"comment_id" gorm:"type:varchar(191);index"`
+	Text      string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func CreateCommentRevision(db *sql.DB, comment Comment) error {
+
+	//save the current text of the comment before it is changed
+	_, err := db.Exec(`INSERT INTO comment_revisions (id, comment_id, text, created_at)
+						VALUES (?, ?, ?, ?)`, uuid.New().String(), comment.ID, comment.Text, time.Now())
+	return err
+}
+
+func GetCommentRevisions(db *sql.DB, commentID string) ([]CommentRevision, error) {
+
+	//get the previous versions of the comment, the oldest first
+	var revisions []CommentRevision
+	rows, err := db.Query(`SELECT id, comment_id, text, created_at
+							FROM comment_revisions
+							WHERE comment_id = ?
+							ORDER BY created_at ASC`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the revisions slice
+	for rows.Next() {
+		var revision CommentRevision
+		if err := rows.
+			Scan(&revision.ID,
+				&revision.CommentID,
+				&revision.Text,
+				&revision.CreatedAt); err != nil {
+			return revisions, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err = rows.Err(); err != nil {
+		return revisions, err
+	}
+	return revisions, nil
+}
+
+// PostRevision keeps the text and the photo that the post had before an edit
+type PostRevision struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	PostID    string    `json:"post_id" gorm:"type:varchar(191);index"`
+	Text      string    `json:"text"`
+	Photo     string    `json:"photo_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostRevisionAPI is one version of the post with the changes that the following edit made
+type PostRevisionAPI struct {
+	Revision     PostRevision    `json:"revision"`
+	Diff         []textdiff.Part `json:"diff"`
+	PhotoChanged bool            `json:"photo_changed"`
+}
+
+func CreatePostRevision(db *sql.DB, post Post, editedAt time.Time) error {
+
+	//save the current version of the post before it is changed
+	_, err := db.Exec(`INSERT INTO post_revisions (id, post_id, text, photo, created_at)
+						VALUES (?, ?, ?, ?, ?)`, uuid.New().String(), post.ID, post.Text, post.Photo, editedAt)
+	return err
+}
+
+func GetPostRevisions(db *sql.DB, postID string) ([]PostRevision, error) {
+
+	//get the previous versions of the post, the oldest first
+	var revisions []PostRevision
+	rows, err := db.Query(`SELECT id, post_id, text, photo, created_at
+							FROM post_revisions
+							WHERE post_id = ?
+							ORDER BY created_at ASC`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the revisions slice
+	for rows.Next() {
+		var revision PostRevision
+		if err := rows.
+			Scan(&revision.ID,
+				&revision.PostID,
+				&revision.Text,
+				&revision.Photo,
+				&revision.CreatedAt); err != nil {
+			return revisions, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err = rows.Err(); err != nil {
+		return revisions, err
+	}
+	return revisions, nil
+}
+
+// PostRevisionDiffs compares every revision with the version that replaced it, the last revision is compared with the current post
+func PostRevisionDiffs(post Post, revisions []PostRevision) []PostRevisionAPI {
+	var diffs []PostRevisionAPI
+	for i, revision := range revisions {
+		nextText, nextPhoto := post.Text, post.Photo
+		if i+1 < len(revisions) {
+			nextText, nextPhoto = revisions[i+1].Text, revisions[i+1].Photo
+		}
+		diffs = append(diffs, PostRevisionAPI{
+			Revision:     revision,
+			Diff:         textdiff.Diff(revision.Text, nextText),
+			PhotoChanged: revision.Photo != nextPhoto,
+		})
+	}
+	return diffs
+}
+
+func DeletePostRevisions(db *sql.DB, postID string) error {
+
+	//delete the edit history of the post
+	_, err := db.Exec(`DELETE
+						FROM post_revisions
+						WHERE post_id = ?`, postID)
+	return err
+}
@@ -45,7 +45,8 @@ func DeleteRoom(db *sql.DB, userID1 string, userID2 string) error {
 
 	//delete the chat room
 	room, _ := FindChatRoom(db, userID1, userID2)
-	_, err := db.Exec(`DELETE rooms 
+	_, err := db.Exec(`DELETE 
+						FROM rooms 
 						WHERE id = ?`, room.ID)
 	return err
 }
@@ -56,7 +57,7 @@ func FindChatRoomUsers(db *sql.DB, roomID string) (string, string) {
 	var user1, user2 string
 	if err := db.QueryRow(`SELECT user1_id, user2_id 
 							FROM rooms 
-							WHERE id = ?)`, roomID).Scan(&user1, &user2); err != nil {
+							WHERE id = ?`, roomID).Scan(&user1, &user2); err != nil {
 		return "", ""
 	}
 	return user1, user2
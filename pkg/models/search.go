@@ -0,0 +1,296 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/search"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxSearchHits limits the hits that are checked against the blocks and the privacy settings in one query
+const maxSearchHits = 500
+
+// fields of the users and the posts in the search index
+const (
+	searchFieldUsername    = "username"
+	searchFieldName        = "name"
+	searchFieldDescription = "description"
+	searchFieldText        = "text"
+)
+
+func userDocument(user User) search.Document {
+	return search.Document{
+		Kind: search.KindUser,
+		ID:   user.ID,
+		Time: user.CreatedAt,
+		Fields: []search.Field{
+			{Name: searchFieldUsername, Text: user.Username, Boost: 3},
+			{Name: searchFieldName, Text: user.FirstName + " " + user.LastName, Boost: 2},
+			{Name: searchFieldDescription, Text: user.ProfileDescription, Boost: 0.5},
+		},
+	}
+}
+
+func postDocument(post Post) search.Document {
+	createdAt := post.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return search.Document{
+		Kind:   search.KindPost,
+		ID:     post.ID,
+		Time:   createdAt,
+		Tags:   ExtractHashtags(post.Text),
+		Fields: []search.Field{{Name: searchFieldText, Text: post.Text, Boost: 1}},
+	}
+}
+
+// indexPost keeps only the live posts in the search index, the drafts and the hidden posts are removed from it
+func indexPost(post Post) {
+	if post.IsLive() {
+		initializers.SearchIndex.Index(postDocument(post))
+	} else {
+		initializers.SearchIndex.Remove(search.KindPost, post.ID)
+	}
+}
+
+// reindexPost reads the post again after its status changed
+func reindexPost(db *sql.DB, id string) {
+	if post, err := GetPostByID(db, id); err == nil {
+		indexPost(post)
+	}
+}
+
+func BuildSearchIndex(db *sql.DB, index search.SearchIndex) error {
+
+	//index all users, the privacy settings are checked when they are found
+	rows, err := db.Query(`SELECT id, username, first_name, last_name, profile_description, created_at
+							FROM users`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.FirstName, &user.LastName, &user.ProfileDescription, &user.CreatedAt); err != nil {
+			return err
+		}
+		index.Index(userDocument(user))
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	//index the live posts
+	postRows, err := db.Query(`SELECT ` + postColumns + `
+								FROM posts
+								WHERE hidden = false AND status = 'published'`)
+	if err != nil {
+		return err
+	}
+	defer postRows.Close()
+	for postRows.Next() {
+		post, err := scanPost(postRows)
+		if err != nil {
+			return err
+		}
+		index.Index(postDocument(post))
+	}
+	return postRows.Err()
+}
+
+// hitIDs returns the ids of the found documents in the order of the hits
+func hitIDs(hits []search.Hit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+// searchableUser is the found user with the result of the description audience check
+type searchableUser struct {
+	user               User
+	descriptionVisible bool
+}
+
+// getSearchableUsers checks all found users at once, the users that turned off the search and the blocked users are left out
+func getSearchableUsers(db *sql.DB, viewerID string, ids []string) (map[string]searchableUser, error) {
+	query, args, err := sqlx.In(`SELECT users.id, users.email, users.username, users.first_name, users.last_name, users.profile_description, users.user_photo_url, users.profile_type,
+									users.id = ? OR COALESCE(privacy_settings.description_audience, 'everyone') = 'everyone'
+										OR (privacy_settings.description_audience = 'friends' AND EXISTS (SELECT 1 FROM friendships
+											WHERE friendships.status = 'accepted'
+												AND ((friendships.user_sent_req_id = ? AND friendships.user_got_req_id = users.id)
+													OR (friendships.user_got_req_id = ? AND friendships.user_sent_req_id = users.id))))
+								FROM users
+								LEFT JOIN privacy_settings ON privacy_settings.user_id = users.id
+								WHERE users.id IN (?) AND (users.id = ? OR (COALESCE(privacy_settings.searchable, true) = true
+									AND users.id NOT IN (SELECT user_blocked_id FROM blocks WHERE user_block_id = ?
+										UNION
+										SELECT user_block_id FROM blocks WHERE user_blocked_id = ?)))`,
+		viewerID, viewerID, viewerID, ids, viewerID, viewerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]searchableUser)
+	for rows.Next() {
+		var found searchableUser
+		if err := rows.Scan(
+			&found.user.ID,
+			&found.user.Email,
+			&found.user.Username,
+			&found.user.FirstName,
+			&found.user.LastName,
+			&found.user.ProfileDescription,
+			&found.user.UserPhotoURL,
+			&found.user.ProfileType,
+			&found.descriptionVisible); err != nil {
+			return users, err
+		}
+		users[found.user.ID] = found
+	}
+	return users, rows.Err()
+}
+
+func SearchUsers(db *sql.DB, viewerID string, text string, limit int, offset int) ([]User, error) {
+
+	//find the users, the best matches first
+	hits, err := initializers.SearchIndex.Search(search.Query{Text: text, Kinds: []string{search.KindUser}, Limit: maxSearchHits})
+	if err != nil || len(hits) == 0 {
+		return nil, err
+	}
+
+	//check the blocks and the privacy settings of all found users in one query
+	found, err := getSearchableUsers(db, viewerID, hitIDs(hits))
+	if err != nil {
+		return nil, err
+	}
+
+	//keep one page of the users that the viewer can find, the users found only by the description that the viewer is not allowed to see are left out
+	var users []User
+	skipped := 0
+	for _, hit := range hits {
+		user, ok := found[hit.ID]
+		if !ok || !(user.descriptionVisible || hit.MatchesWithout(searchFieldDescription)) {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		users = append(users, user.user)
+		if len(users) == limit {
+			break
+		}
+	}
+	return users, nil
+}
+
+func SearchPosts(db *sql.DB, viewerID string, text string, limit int, offset int) ([]Post, error) {
+
+	//find the posts, the best matches first
+	hits, err := initializers.SearchIndex.Search(search.Query{Text: text, Kinds: []string{search.KindPost}, Limit: maxSearchHits})
+	if err != nil || len(hits) == 0 {
+		return nil, err
+	}
+
+	//get all found posts that the viewer can see in one query
+	query, args, err := sqlx.In(`SELECT `+postColumns+`
+								FROM posts
+								INNER JOIN users ON users.id = posts.user_id
+								WHERE posts.id IN (?) AND `+postVisibleCondition, append([]interface{}{hitIDs(hits)}, postVisibleArgs(viewerID)...)...)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := scanPosts(db.Query(query, args...))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]Post)
+	for _, post := range visible {
+		found[post.ID] = post
+	}
+
+	//keep one page of the posts in the order of the hits
+	var posts []Post
+	skipped := 0
+	for _, hit := range hits {
+		post, ok := found[hit.ID]
+		if !ok {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		posts = append(posts, post)
+		if len(posts) == limit {
+			break
+		}
+	}
+	return posts, nil
+}
+
+func SearchHashtags(db *sql.DB, viewerID string, text string, limit int, offset int) ([]HashtagAPI, error) {
+
+	//find the hashtags, the tag can be written with or without #
+	hits, err := initializers.SearchIndex.Search(search.Query{Text: NormalizeHashtag(text), Kinds: []string{search.KindHashtag}, Limit: maxSearchHits})
+	if err != nil || len(hits) == 0 {
+		return nil, err
+	}
+
+	//count only the posts that the viewer can see, so the hashtags used only in the private posts are not revealed
+	query, args, err := sqlx.In(`SELECT post_hashtags.tag, COUNT(DISTINCT posts.id)
+								FROM post_hashtags
+								INNER JOIN posts ON posts.id = post_hashtags.post_id
+								INNER JOIN users ON users.id = posts.user_id
+								WHERE post_hashtags.tag IN (?) AND `+postVisibleCondition+`
+								GROUP BY post_hashtags.tag`, append([]interface{}{hitIDs(hits)}, postVisibleArgs(viewerID)...)...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, err
+		}
+		counts[tag] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//keep one page of the hashtags in the order of the hits
+	var hashtags []HashtagAPI
+	skipped := 0
+	for _, hit := range hits {
+		if counts[hit.ID] == 0 {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		hashtags = append(hashtags, HashtagAPI{Tag: hit.ID, PostCount: counts[hit.ID]})
+		if len(hashtags) == limit {
+			break
+		}
+	}
+	return hashtags, nil
+}
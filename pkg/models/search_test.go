@@ -0,0 +1,72 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/search"
+)
+
+func TestSearchHashtagsCountsVisiblePosts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	previous := initializers.SearchIndex
+	initializers.SearchIndex = search.NewMemoryIndex()
+	defer func() { initializers.SearchIndex = previous }()
+	now := time.Now()
+	initializers.SearchIndex.Index(postDocument(Post{ID: "public-post", Text: "#summer", CreatedAt: now}))
+	initializers.SearchIndex.Index(postDocument(Post{ID: "private-post", Text: "#summer #summerhouse", CreatedAt: now}))
+
+	//the hashtag used only in the posts that the viewer can not see is left out, and the private posts are not counted
+	mock.ExpectQuery("FROM post_hashtags .* GROUP BY post_hashtags.tag").
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "count"}).AddRow("summer", 1))
+
+	hashtags, err := SearchHashtags(db, "viewer-id", "#summer", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []HashtagAPI{{Tag: "summer", PostCount: 1}}; !reflect.DeepEqual(hashtags, want) {
+		t.Errorf("SearchHashtags() = %v, want %v", hashtags, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSearchUsersHidesDescriptionMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	previous := initializers.SearchIndex
+	initializers.SearchIndex = search.NewMemoryIndex()
+	defer func() { initializers.SearchIndex = previous }()
+	initializers.SearchIndex.Index(userDocument(User{ID: "by-name", FirstName: "Sarajevo", LastName: "Guide"}))
+	initializers.SearchIndex.Index(userDocument(User{ID: "by-description", FirstName: "Amra", ProfileDescription: "Sarajevo"}))
+
+	//the user found only by the description that the viewer can not see is left out
+	mock.ExpectQuery("FROM users LEFT JOIN privacy_settings").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "first_name", "last_name", "profile_description", "user_photo_url", "profile_type", "description_visible"}).
+			AddRow("by-name", "", "", "Sarajevo", "Guide", "", "", "public", false).
+			AddRow("by-description", "", "", "Amra", "", "Sarajevo", "", "public", false))
+
+	users, err := SearchUsers(db, "viewer-id", "sarajevo", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != "by-name" {
+		t.Errorf("SearchUsers() = %v, want only by-name", users)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// StoryLifetime is how long the story is visible after it is shared
+const StoryLifetime = 24 * time.Hour
+
+// Story is a short-lived photo or text, it is visible only to the friends of the author until it expires
+type Story struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(191);index"`
+	Photo     string    `json:"photo"`
+	Text      string    `json:"text"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoryView records that the user has seen the story, every viewer is saved once
+type StoryView struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	StoryID   string    `json:"story_id" gorm:"type:varchar(191);uniqueIndex:idx_story_view_story_viewer"`
+	ViewerID  string    `json:"viewer_id" gorm:"type:varchar(191);uniqueIndex:idx_story_view_story_viewer"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StoryAPI struct {
+	Story
+	Seen bool `json:"seen"`
+}
+
+// StoryTrayItem holds the active stories of one author, the authors with unseen stories are shown first
+type StoryTrayItem struct {
+	UserID string `json:"user_id"`
+	Author
+	AllSeen bool       `json:"all_seen"`
+	Stories []StoryAPI `json:"stories"`
+}
+
+type StoryViewerAPI struct {
+	UserID string `json:"user_id"`
+	Author
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+func (s Story) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+func CreateStory(db *sql.DB, story Story) (Story, error) {
+
+	//the story expires a day after it is shared
+	story.ID = uuid.New().String()
+	story.CreatedAt = time.Now()
+	story.ExpiresAt = story.CreatedAt.Add(StoryLifetime)
+
+	//save the story in the database
+	_, err := db.Exec(`INSERT INTO stories (id, user_id, photo, text, expires_at, created_at)
+						VALUES (?, ?, ?, ?, ?, ?)`, story.ID, story.UserID, story.Photo, story.Text, story.ExpiresAt, story.CreatedAt)
+	return story, err
+}
+
+func GetStoryByID(db *sql.DB, id string) (Story, error) {
+
+	//get the story by id from the database
+	var story Story
+	if err := db.QueryRow(`SELECT id, user_id, photo, text, expires_at, created_at
+							FROM stories
+							WHERE id = ?`, id).
+		Scan(
+			&story.ID,
+			&story.UserID,
+			&story.Photo,
+			&story.Text,
+			&story.ExpiresAt,
+			&story.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return story, errors.New("Story not found in the database")
+		}
+		return story, err
+	}
+	return story, nil
+}
+
+// CanViewStory checks whether the viewer can see the story, only the author and the friends can see it before it expires
+func CanViewStory(db *sql.DB, viewerID string, story Story, now time.Time) bool {
+	if story.IsExpired(now) {
+		return false
+	}
+	if story.UserID == viewerID {
+		return true
+	}
+	friendship, _ := CheckFriendship(db, viewerID, story.UserID)
+	return friendship.Status == "accepted" && CanInteract(db, viewerID, story.UserID, ActionViewPost)
+}
+
+func GetActiveStoriesByUsers(db *sql.DB, userIDs []string, viewerID string, now time.Time) ([]StoryTrayItem, error) {
+
+	//get the stories that did not expire yet, with the info whether the viewer has seen them
+	var tray []StoryTrayItem
+	if len(userIDs) == 0 {
+		return tray, nil
+	}
+	query, args, _ := sqlx.In(`SELECT stories.id, stories.user_id, stories.photo, stories.text, stories.expires_at, stories.created_at,
+									users.first_name, users.last_name, users.user_photo_url,
+									EXISTS (SELECT 1 FROM story_views
+											WHERE story_views.story_id = stories.id AND story_views.viewer_id = ?) AS seen
+								FROM stories
+								INNER JOIN users ON users.id = stories.user_id
+								WHERE stories.user_id IN (?) AND stories.expires_at > ?
+								ORDER BY stories.created_at ASC`, viewerID, userIDs, now)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//group the stories by the author, the oldest story of the author comes first
+	positions := make(map[string]int)
+	for rows.Next() {
+		var story StoryAPI
+		var author Author
+		if err := rows.
+			Scan(&story.ID,
+				&story.UserID,
+				&story.Photo,
+				&story.Text,
+				&story.ExpiresAt,
+				&story.CreatedAt,
+				&author.FirstName,
+				&author.LastName,
+				&author.UserPhotoURL,
+				&story.Seen); err != nil {
+			return tray, err
+		}
+		position, found := positions[story.UserID]
+		if !found {
+			position = len(tray)
+			positions[story.UserID] = position
+			tray = append(tray, StoryTrayItem{UserID: story.UserID, Author: author, AllSeen: true})
+		}
+		tray[position].Stories = append(tray[position].Stories, story)
+		tray[position].AllSeen = tray[position].AllSeen && story.Seen
+	}
+	if err = rows.Err(); err != nil {
+		return tray, err
+	}
+	return tray, nil
+}
+
+// SortStoryTray puts the authors with unseen stories first, and otherwise the authors with the newest stories first
+func SortStoryTray(tray []StoryTrayItem) {
+	latest := func(item StoryTrayItem) time.Time {
+		return item.Stories[len(item.Stories)-1].CreatedAt
+	}
+	sort.SliceStable(tray, func(i, j int) bool {
+		if tray[i].AllSeen != tray[j].AllSeen {
+			return !tray[i].AllSeen
+		}
+		return latest(tray[i]).After(latest(tray[j]))
+	})
+}
+
+func MarkStorySeen(db *sql.DB, storyID string, viewerID string) error {
+
+	//save the view, the story that is already seen by the viewer is skipped
+	_, err := db.Exec(`INSERT IGNORE INTO story_views (id, story_id, viewer_id)
+						VALUES (?, ?, ?)`, uuid.New().String(), storyID, viewerID)
+	return err
+}
+
+func GetStoryViewers(db *sql.DB, storyID string) ([]StoryViewerAPI, error) {
+
+	//get the users that have seen the story, the latest views first
+	var viewers []StoryViewerAPI
+	rows, err := db.Query(`SELECT story_views.viewer_id, users.first_name, users.last_name, users.user_photo_url, story_views.created_at
+							FROM story_views
+							INNER JOIN users ON users.id = story_views.viewer_id
+							WHERE story_views.story_id = ?
+							ORDER BY story_views.created_at DESC`, storyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the viewers slice
+	for rows.Next() {
+		var viewer StoryViewerAPI
+		if err := rows.
+			Scan(&viewer.UserID,
+				&viewer.FirstName,
+				&viewer.LastName,
+				&viewer.UserPhotoURL,
+				&viewer.ViewedAt); err != nil {
+			return viewers, err
+		}
+		viewers = append(viewers, viewer)
+	}
+	if err = rows.Err(); err != nil {
+		return viewers, err
+	}
+	return viewers, nil
+}
+
+func DeleteStory(db *sql.DB, id string) error {
+
+	//delete the views of the story
+	if _, err := db.Exec(`DELETE
+							FROM story_views
+							WHERE story_id = ?`, id); err != nil {
+		return err
+	}
+
+	//delete the story in the database
+	_, err := db.Exec(`DELETE
+						FROM stories
+						WHERE id = ?`, id)
+	return err
+}
+
+func GetExpiredStories(db *sql.DB, now time.Time) ([]Story, error) {
+
+	//get the stories whose time has passed
+	var stories []Story
+	rows, err := db.Query(`SELECT id, user_id, photo, text, expires_at, created_at
+							FROM stories
+							WHERE expires_at <= ?`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//loop through the rows of the result and fullfill the stories slice
+	for rows.Next() {
+		var story Story
+		if err := rows.
+			Scan(&story.ID,
+				&story.UserID,
+				&story.Photo,
+				&story.Text,
+				&story.ExpiresAt,
+				&story.CreatedAt); err != nil {
+			return stories, err
+		}
+		stories = append(stories, story)
+	}
+	if err = rows.Err(); err != nil {
+		return stories, err
+	}
+	return stories, nil
+}
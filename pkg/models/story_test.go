@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortStoryTray(t *testing.T) {
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	item := func(userID string, allSeen bool, hoursAgo int) StoryTrayItem {
+		story := StoryAPI{Story: Story{UserID: userID, CreatedAt: now.Add(-time.Duration(hoursAgo) * time.Hour)}, Seen: allSeen}
+		return StoryTrayItem{UserID: userID, AllSeen: allSeen, Stories: []StoryAPI{story}}
+	}
+	tray := []StoryTrayItem{
+		item("seen-new", true, 1),
+		item("unseen-old", false, 5),
+		item("seen-old", true, 6),
+		item("unseen-new", false, 2),
+	}
+
+	SortStoryTray(tray)
+
+	want := []string{"unseen-new", "unseen-old", "seen-new", "seen-old"}
+	for i, userID := range want {
+		if tray[i].UserID != userID {
+			t.Errorf("tray[%d] = %s, want %s", i, tray[i].UserID, userID)
+		}
+	}
+}
+
+func TestStoryIsExpired(t *testing.T) {
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	story := Story{ExpiresAt: now.Add(StoryLifetime)}
+
+	if story.IsExpired(now.Add(StoryLifetime - time.Second)) {
+		t.Error("IsExpired() before the lifetime = true, want false")
+	}
+	if !story.IsExpired(now.Add(StoryLifetime)) {
+		t.Error("IsExpired() after the lifetime = false, want true")
+	}
+}
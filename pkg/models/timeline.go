@@ -0,0 +1,234 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timeline is one post in the home feed of the user. The posts are written to the timelines of the friends
+// when they are published (fan-out on write), so reading the feed is one range scan of the user's timeline.
+type Timeline struct {
+	UserID    string    `json:"user_id" gorm:"primaryKey;type:varchar(191);index:idx_timeline_user_time,priority:1"`
+	PostID    string    `json:"post_id" gorm:"primaryKey;type:varchar(191);index"`
+	AuthorID  string    `json:"author_id" gorm:"type:varchar(191);index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_timeline_user_time,priority:2"`
+}
+
+// Celebrity is the author with too many friends to write every post to all of their timelines,
+// the posts of the celebrities are read from the posts table when the feed is built (fan-out on read)
+type Celebrity struct {
+	UserID    string    `json:"user_id" gorm:"primaryKey;type:varchar(191)"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	// timelineBackfillLimit is the number of the newest posts of the author copied to the timeline of the new friend
+	timelineBackfillLimit = 200
+	// timelineInsertBatch is the number of the timelines written with one insert
+	timelineInsertBatch = 500
+)
+
+func CountFriends(db *sql.DB, id string) (int, error) {
+
+	//count the accepted friendships of the user
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*)
+						FROM friendships
+						WHERE (user_sent_req_id = ? OR user_got_req_id = ?) AND status = 'accepted'`, id, id).Scan(&count)
+	return count, err
+}
+
+func IsCelebrity(db *sql.DB, id string) (bool, error) {
+
+	//check whether the posts of the user are read on the fan-out
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*)
+						FROM celebrities
+						WHERE user_id = ?`, id).Scan(&count)
+	return count > 0, err
+}
+
+func SetCelebrity(db *sql.DB, id string, celebrity bool, now time.Time) error {
+
+	//mark or unmark the user as the celebrity
+	if celebrity {
+		_, err := db.Exec(`INSERT IGNORE INTO celebrities (user_id, updated_at)
+							VALUES (?, ?)`, id, now)
+		return err
+	}
+	_, err := db.Exec(`DELETE
+						FROM celebrities
+						WHERE user_id = ?`, id)
+	return err
+}
+
+func AddToTimelines(db *sql.DB, post Post, userIDs []string) error {
+
+	//write the post to the timelines in batches, the post that is already in the timeline is skipped
+	for start := 0; start < len(userIDs); start += timelineInsertBatch {
+		end := start + timelineInsertBatch
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		var values []string
+		var args []interface{}
+		for _, userID := range userIDs[start:end] {
+			values = append(values, "(?, ?, ?, ?)")
+			args = append(args, userID, post.ID, post.UserID, post.CreatedAt)
+		}
+		if _, err := db.Exec(`INSERT IGNORE INTO timelines (user_id, post_id, author_id, created_at)
+								VALUES `+strings.Join(values, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func BackfillTimeline(db *sql.DB, userID string, authorID string) error {
+
+	//copy the newest published posts of the author to the timeline of the user
+	_, err := db.Exec(`INSERT IGNORE INTO timelines (user_id, post_id, author_id, created_at)
+						SELECT ?, id, user_id, created_at
+						FROM posts
+						WHERE user_id = ? AND status = 'published'
+						ORDER BY created_at DESC
+						LIMIT ?`, userID, authorID, timelineBackfillLimit)
+	return err
+}
+
+func RemoveFromTimeline(db *sql.DB, userID string, authorID string) error {
+
+	//remove the posts of the author from the timeline of the user
+	_, err := db.Exec(`DELETE
+						FROM timelines
+						WHERE user_id = ? AND author_id = ?`, userID, authorID)
+	return err
+}
+
+func RemoveAuthorFromTimelines(db *sql.DB, authorID string) error {
+
+	//remove the posts of the author from all timelines
+	_, err := db.Exec(`DELETE
+						FROM timelines
+						WHERE author_id = ?`, authorID)
+	return err
+}
+
+func RemovePostFromTimelines(db *sql.DB, postID string) error {
+
+	//remove the post from all timelines
+	_, err := db.Exec(`DELETE
+						FROM timelines
+						WHERE post_id = ?`, postID)
+	return err
+}
+
+func ClearTimeline(db *sql.DB, userID string) error {
+
+	//remove all posts from the timeline of the user
+	_, err := db.Exec(`DELETE
+						FROM timelines
+						WHERE user_id = ?`, userID)
+	return err
+}
+
+func GetAllUserIDs(db *sql.DB) ([]string, error) {
+
+	//get the ids of all users
+	var ids []string
+	rows, err := db.Query(`SELECT id
+							FROM users`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return ids, err
+	}
+	return ids, nil
+}
+
+// scanPosts reads the posts from the rows
+func scanPosts(rows *sql.Rows, err error) ([]Post, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return posts, err
+		}
+		posts = append(posts, post)
+	}
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+	return posts, nil
+}
+
+// GetTimelinePosts returns the newest live posts of the home feed of the user created after since. The posts of the friends
+// are read from the timeline, the posts of the celebrity friends from the posts table, and the posts of the muted users are skipped.
+func GetTimelinePosts(db *sql.DB, userID string, since time.Time, limit int) ([]Post, error) {
+
+	//read the timeline of the user
+	posts, err := scanPosts(db.Query(`SELECT `+postColumns+`
+										FROM timelines
+										INNER JOIN posts ON posts.id = timelines.post_id
+										WHERE timelines.user_id = ? AND timelines.created_at >= ?
+											AND posts.hidden = false AND posts.status = 'published'
+											AND timelines.author_id NOT IN (SELECT user_muted_id FROM mutes WHERE user_mute_id = ?)
+										ORDER BY timelines.created_at DESC
+										LIMIT ?`, userID, since, userID, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	//read the posts of the celebrity friends
+	celebrityPosts, err := scanPosts(db.Query(`SELECT `+postColumns+`
+												FROM posts
+												INNER JOIN celebrities ON celebrities.user_id = posts.user_id
+												INNER JOIN friendships ON friendships.status = 'accepted'
+													AND ((friendships.user_sent_req_id = ? AND friendships.user_got_req_id = posts.user_id)
+														OR (friendships.user_got_req_id = ? AND friendships.user_sent_req_id = posts.user_id))
+												WHERE posts.created_at >= ? AND posts.hidden = false AND posts.status = 'published'
+													AND posts.user_id NOT IN (SELECT user_muted_id FROM mutes WHERE user_mute_id = ?)
+												ORDER BY posts.created_at DESC
+												LIMIT ?`, userID, userID, since, userID, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return mergePosts(posts, celebrityPosts, limit), nil
+}
+
+// mergePosts joins the lists of the posts, the newest first, without the duplicates
+func mergePosts(first []Post, second []Post, limit int) []Post {
+	seen := make(map[string]bool)
+	var merged []Post
+	for _, post := range append(first, second...) {
+		if !seen[post.ID] {
+			seen[post.ID] = true
+			merged = append(merged, post)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
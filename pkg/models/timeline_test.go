@@ -0,0 +1,25 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergePosts(t *testing.T) {
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	post := func(id string, hoursAgo int) Post {
+		return Post{ID: id, CreatedAt: now.Add(-time.Duration(hoursAgo) * time.Hour)}
+	}
+	timeline := []Post{post("friend-new", 1), post("friend-old", 5)}
+	celebrity := []Post{post("celebrity", 3), post("friend-new", 1), post("celebrity-old", 9)}
+
+	//the posts are ordered by the creation time, the duplicates are skipped and the list is cut to the limit
+	var ids []string
+	for _, post := range mergePosts(timeline, celebrity, 3) {
+		ids = append(ids, post.ID)
+	}
+	if expected := []string{"friend-new", "celebrity", "friend-old"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/search"
 )
 
 type User struct {
@@ -53,6 +54,9 @@ func CreateUser(db *sql.DB, userID string, body User, hash []byte) (User, error)
 		user.ProfileType,
 		"",
 		"")
+	if err == nil {
+		initializers.SearchIndex.Index(userDocument(user))
+	}
 
 	return user, err
 }
@@ -136,6 +140,7 @@ func UpdateUser(db *sql.DB, user User) (User, error) {
 	if err != nil {
 		return user, err
 	}
+	initializers.SearchIndex.Index(userDocument(user))
 
 	user.Password = ""
 	return user, nil
@@ -143,54 +148,24 @@ func UpdateUser(db *sql.DB, user User) (User, error) {
 
 func DeleteUser(db *sql.DB, id string) error {
 
-	//delete the user in the database
+	//delete the user in the database and in the search index
 	_, err := db.Exec(`DELETE 
 						FROM users 
 						WHERE id = ?`, id)
+	if err == nil {
+		initializers.SearchIndex.Remove(search.KindUser, id)
+	}
 	return err
 }
 
 func GetUsers(db *sql.DB) ([]User, error) {
 
-	//get the users from the database
+	//get the users that can be found in the search from the database
 	var users []User
-	rows, err := db.Query(`SELECT id, email, username, first_name, last_name, profile_description, user_photo_url, profile_type 
-							FROM users`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	//loop through the rows in the result and fullfill the users slice
-	for rows.Next() {
-		var user User
-		if err := rows.
-			Scan(&user.ID,
-				&user.Email,
-				&user.Username,
-				&user.FirstName,
-				&user.LastName,
-				&user.ProfileDescription,
-				&user.UserPhotoURL,
-				&user.ProfileType); err != nil {
-			return users, err
-		}
-		users = append(users, user)
-	}
-	if err = rows.Err(); err != nil {
-		return users, err
-	}
-
-	return users, nil
-}
-
-func GetUsersByName(db *sql.DB, name string) ([]User, error) {
-
-	//serach for the users by name
-	var users []User
-	rows, err := db.Query(`SELECT id, email, username, first_name, last_name, profile_description, user_photo_url, profile_type 
+	rows, err := db.Query(`SELECT users.id, users.email, users.username, users.first_name, users.last_name, users.profile_description, users.user_photo_url, users.profile_type 
 							FROM users 
-							WHERE first_name LIKE ? OR last_name LIKE ?`, name, name)
+							LEFT JOIN privacy_settings ON privacy_settings.user_id = users.id 
+							WHERE privacy_settings.searchable IS NULL OR privacy_settings.searchable = true`)
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +194,10 @@ func GetUsersByName(db *sql.DB, name string) ([]User, error) {
 	return users, nil
 }
 
-func UserInfo(user User) UserAPI {
+func UserInfo(user User, viewerID string) UserAPI {
+	//remove the fields hidden by the privacy settings of the user
+	user = ApplyPrivacySettings(initializers.DB, user, viewerID)
+
 	//find the number of posts
 	numberOfPosts := GetNumberOfPostsByUserID(initializers.DB, user.ID)
 
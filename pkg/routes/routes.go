@@ -23,11 +23,17 @@ var Router = func(r *gin.Engine) {
 
 	r.GET("/notifications", middleware.RequireAuth, controllers.ReadNotifications)
 	r.GET("/users", middleware.RequireAuth, controllers.SearchUser)
+	r.GET("/search", middleware.RequireAuth, controllers.Search)
+	r.GET("/search/autocomplete", middleware.RequireAuth, controllers.SearchAutocomplete)
 
 	r.GET("/friendshipRequests", middleware.RequireAuth, controllers.ShowFriendshipRequests)
 	r.GET("/friendshipStatus/:id", middleware.RequireAuth, controllers.FriendshipStatus)
 
 	r.GET("/posts", middleware.RequireAuth, controllers.DisplayPostsOnHomePage)
+	r.GET("/explore", middleware.RequireAuth, controllers.ShowExplore)
+
+	r.GET("/hashtag/:tag", middleware.RequireAuth, controllers.ShowHashtagPosts)
+	r.GET("/hashtags/trending", middleware.RequireAuth, controllers.ShowTrendingHashtags)
 
 	user := r.Group("/user")
 	{
@@ -35,6 +41,15 @@ var Router = func(r *gin.Engine) {
 		user.PUT("/", middleware.RequireAuth, controllers.UpdateUser)
 		user.DELETE("/", middleware.RequireAuth, controllers.DeleteUser)
 
+		user.GET("/privacy", middleware.RequireAuth, controllers.ShowPrivacySettings)
+		user.PUT("/privacy", middleware.RequireAuth, controllers.UpdatePrivacySettings)
+
+		user.GET("/drafts", middleware.RequireAuth, controllers.ShowDrafts)
+		user.GET("/saved", middleware.RequireAuth, controllers.ShowSavedPosts)
+		user.GET("/collections", middleware.RequireAuth, controllers.ShowCollections)
+		user.POST("/collections", middleware.RequireAuth, controllers.CreateCollection)
+		user.DELETE("/collections/:id", middleware.RequireAuth, controllers.DeleteCollection)
+
 		user.GET("/:id", middleware.RequireAuth, controllers.DisplayProfile)
 
 		user.GET("/:id/posts", middleware.RequireAuth, controllers.DisplayPostsByUserId)
@@ -47,6 +62,14 @@ var Router = func(r *gin.Engine) {
 		user.GET("/blocked-users", middleware.RequireAuth, controllers.ShowBlockedUsers)
 		user.POST("/:id/block", middleware.RequireAuth, controllers.BlockUser)
 		user.DELETE("/:id/unblock", middleware.RequireAuth, controllers.UnblockUser)
+
+		user.GET("/muted-users", middleware.RequireAuth, controllers.ShowMutedUsers)
+		user.POST("/:id/mute", middleware.RequireAuth, controllers.MuteUser)
+		user.DELETE("/:id/unmute", middleware.RequireAuth, controllers.UnmuteUser)
+
+		user.GET("/restricted-users", middleware.RequireAuth, controllers.ShowRestrictedUsers)
+		user.POST("/:id/restrict", middleware.RequireAuth, controllers.RestrictUser)
+		user.DELETE("/:id/unrestrict", middleware.RequireAuth, controllers.UnrestrictUser)
 	}
 
 	friendshipRequest := r.Group("/friendshipRequest")
@@ -61,18 +84,56 @@ var Router = func(r *gin.Engine) {
 		post.GET("/:id", middleware.RequireAuth, controllers.DisplayPost)
 		post.PUT("/:id", middleware.RequireAuth, controllers.UpdatePost)
 		post.DELETE("/:id", middleware.RequireAuth, controllers.DeletePost)
+		post.GET("/:id/revisions", middleware.RequireAuth, controllers.ShowPostRevisions)
+		post.POST("/:id/repost", middleware.RequireAuth, controllers.RepostPost)
+		post.POST("/:id/save", middleware.RequireAuth, controllers.SavePost)
+		post.DELETE("/:id/save", middleware.RequireAuth, controllers.UnsavePost)
+		post.POST("/:id/publish", middleware.RequireAuth, controllers.PublishPost)
+		post.PUT("/:id/schedule", middleware.RequireAuth, controllers.SchedulePost)
+		post.POST("/:id/vote", middleware.RequireAuth, controllers.VotePoll)
+		post.POST("/:id/pin", middleware.RequireAuth, controllers.PinPost)
+		post.DELETE("/:id/pin", middleware.RequireAuth, controllers.UnpinPost)
 
 		post.POST("/:id/like", middleware.RequireAuth, controllers.LikePost)
+		post.GET("/:id/likes", middleware.RequireAuth, controllers.ShowPostLikes)
+		post.POST("/:id/react", middleware.RequireAuth, controllers.ReactToPost)
+		post.GET("/:id/reactions", middleware.RequireAuth, controllers.ShowPostReactions)
 		post.POST("/:id/comment", middleware.RequireAuth, controllers.AddComment)
 	}
 
+	r.PUT("/comment/:id", middleware.RequireAuth, controllers.UpdateComment)
 	r.DELETE("/comment/:id", middleware.RequireAuth, controllers.DeleteComment)
+	r.GET("/comment/:id/revisions", middleware.RequireAuth, controllers.ShowCommentRevisions)
+	r.GET("/comment/:id/replies", middleware.RequireAuth, controllers.ShowCommentReplies)
+	r.POST("/comment/:id/like", middleware.RequireAuth, controllers.LikeComment)
+	r.POST("/comment/:id/react", middleware.RequireAuth, controllers.ReactToComment)
+	r.PUT("/comment/:id/approve", middleware.RequireAuth, controllers.ApproveComment)
+
+	r.GET("/stories", middleware.RequireAuth, controllers.ShowStoryTray)
+	r.POST("/stories", middleware.RequireAuth, controllers.CreateStory)
+	r.GET("/stories/:id", middleware.RequireAuth, controllers.DisplayStory)
+	r.DELETE("/stories/:id", middleware.RequireAuth, controllers.DeleteStory)
+	r.GET("/stories/:id/viewers", middleware.RequireAuth, controllers.ShowStoryViewers)
+	r.POST("/stories/:id/reply", middleware.RequireAuth, controllers.ReplyToStory)
 
 	r.GET("/chatroom/:userID", middleware.RequireAuth, controllers.OpenChatRoom)
 
+	r.POST("/report", middleware.RequireAuth, controllers.ReportContent)
+
+	moderation := r.Group("/moderation")
+	{
+		moderation.GET("/reports", middleware.RequireAuth, middleware.RequireModerator, controllers.ShowReportQueue)
+		moderation.GET("/reports/:type/:targetID", middleware.RequireAuth, middleware.RequireModerator, controllers.ShowTargetReports)
+		moderation.POST("/reports/:type/:targetID/action", middleware.RequireAuth, middleware.RequireModerator, controllers.ActionReports)
+		moderation.POST("/reports/:type/:targetID/dismiss", middleware.RequireAuth, middleware.RequireModerator, controllers.DismissReports)
+	}
+
 	wsServer := websocketrooms.NewWebsocketServer()
 	go wsServer.Run()
 
+	//the controllers push the live feed events through the same server
+	websocketrooms.Hub = wsServer
+
 	r.GET("/websocket/:userID", func(c *gin.Context) {
 		userID := c.Param("userID")
 		websocketrooms.ServeWs(wsServer, c.Writer, c.Request, userID)
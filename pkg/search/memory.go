@@ -0,0 +1,208 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+type docKey struct {
+	kind string
+	id   string
+}
+
+type indexedDoc struct {
+	doc Document
+	// the number of the times every term is found in every field
+	terms map[string]map[string]int
+	boost map[string]float64
+}
+
+// MemoryIndex is the inverted index kept in the memory of the server, it is filled from the database on start
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*indexedDoc
+	postings map[string]map[docKey]bool
+	hashtags map[string]int
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[docKey]*indexedDoc),
+		postings: make(map[string]map[docKey]bool),
+		hashtags: make(map[string]int),
+	}
+}
+
+// Index adds the document, the document that is already in the index is replaced
+func (index *MemoryIndex) Index(doc Document) error {
+	indexed := &indexedDoc{doc: doc, terms: make(map[string]map[string]int), boost: make(map[string]float64)}
+	for _, field := range doc.Fields {
+		indexed.boost[field.Name] = field.Boost
+		for _, term := range Tokenize(field.Text) {
+			if indexed.terms[term] == nil {
+				indexed.terms[term] = make(map[string]int)
+			}
+			indexed.terms[term][field.Name]++
+		}
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	key := docKey{doc.Kind, doc.ID}
+	index.remove(key)
+	index.docs[key] = indexed
+	for term := range indexed.terms {
+		if index.postings[term] == nil {
+			index.postings[term] = make(map[docKey]bool)
+		}
+		index.postings[term][key] = true
+	}
+	for _, tag := range doc.Tags {
+		index.hashtags[tag]++
+	}
+	return nil
+}
+
+func (index *MemoryIndex) Remove(kind string, id string) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.remove(docKey{kind, id})
+	return nil
+}
+
+func (index *MemoryIndex) remove(key docKey) {
+	indexed, found := index.docs[key]
+	if !found {
+		return
+	}
+	delete(index.docs, key)
+	for term := range indexed.terms {
+		delete(index.postings[term], key)
+		if len(index.postings[term]) == 0 {
+			delete(index.postings, term)
+		}
+	}
+	for _, tag := range indexed.doc.Tags {
+		if index.hashtags[tag]--; index.hashtags[tag] <= 0 {
+			delete(index.hashtags, tag)
+		}
+	}
+}
+
+type termMatch struct {
+	term    string
+	quality float64
+}
+
+type wordScore struct {
+	score  float64
+	fields []string
+}
+
+// Search finds the documents that match every word of the query exactly, by the prefix or with a few typos.
+// The documents are ranked by the quality of the match, the rarity of the words and the boost of the fields.
+func (index *MemoryIndex) Search(query Query) ([]Hit, error) {
+	var words [][]rune
+	for _, word := range Tokenize(query.Text) {
+		if slices.IndexFunc(words, func(found []rune) bool { return string(found) == word }) == -1 {
+			words = append(words, []rune(word))
+		}
+	}
+	if len(words) == 0 {
+		return nil, nil
+	}
+	searchKind := func(kind string) bool {
+		return len(query.Kinds) == 0 || slices.Contains(query.Kinds, kind)
+	}
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	//score every word in every document, only the best matching term of the word counts
+	scores := make(map[docKey][]wordScore)
+	total := float64(len(index.docs))
+	for i, word := range words {
+		for _, match := range index.matchTerms(word) {
+			postings := index.postings[match.term]
+			idf := math.Log(1 + total/float64(len(postings)))
+			for key := range postings {
+				if !searchKind(key.kind) {
+					continue
+				}
+				indexed := index.docs[key]
+				if scores[key] == nil {
+					scores[key] = make([]wordScore, len(words))
+				}
+				for field, count := range indexed.terms[match.term] {
+					score := match.quality * idf * indexed.boost[field] * float64(count) / float64(count+1)
+					word := &scores[key][i]
+					if score > word.score {
+						word.score = score
+					}
+					if !slices.Contains(word.fields, field) {
+						word.fields = append(word.fields, field)
+					}
+				}
+			}
+		}
+	}
+
+	//keep the documents that match every word
+	var hits []Hit
+	times := make(map[docKey]time.Time)
+	for key, wordScores := range scores {
+		hit := Hit{Kind: key.kind, ID: key.id}
+		for _, word := range wordScores {
+			if len(word.fields) == 0 {
+				hit.TermFields = nil
+				break
+			}
+			hit.Score += word.score
+			hit.TermFields = append(hit.TermFields, word.fields)
+		}
+		if hit.TermFields != nil {
+			hits = append(hits, hit)
+			times[key] = index.docs[key].doc.Time
+		}
+	}
+
+	//the hashtag is one word, the more posts use it the higher it ranks
+	if searchKind(KindHashtag) && len(words) == 1 {
+		for tag, count := range index.hashtags {
+			if quality := matchQuality(words[0], tag); quality > 0 {
+				hits = append(hits, Hit{Kind: KindHashtag, ID: tag, Score: quality * (1 + math.Log(float64(count))), Count: count, TermFields: [][]string{{"tag"}}})
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		timeI, timeJ := times[docKey{hits[i].Kind, hits[i].ID}], times[docKey{hits[j].Kind, hits[j].ID}]
+		if !timeI.Equal(timeJ) {
+			return timeI.After(timeJ)
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+// matchTerms finds the indexed terms that match the word
+func (index *MemoryIndex) matchTerms(word []rune) []termMatch {
+	var matches []termMatch
+	for term := range index.postings {
+		if quality := matchQuality(word, term); quality > 0 {
+			matches = append(matches, termMatch{term, quality})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testIndex() *MemoryIndex {
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	index := NewMemoryIndex()
+	index.Index(Document{Kind: KindUser, ID: "john", Time: now, Fields: []Field{
+		{Name: "username", Text: "john_doe", Boost: 3},
+		{Name: "name", Text: "John Doe", Boost: 2},
+		{Name: "description", Text: "Photographer from Sarajevo", Boost: 1},
+	}})
+	index.Index(Document{Kind: KindUser, ID: "jane", Time: now, Fields: []Field{
+		{Name: "username", Text: "jane", Boost: 3},
+		{Name: "name", Text: "Jane Johnson", Boost: 2},
+	}})
+	index.Index(Document{Kind: KindPost, ID: "old-post", Time: now.Add(-time.Hour), Tags: []string{"travel"}, Fields: []Field{
+		{Name: "text", Text: "Sunset in Mostar #travel", Boost: 1},
+	}})
+	index.Index(Document{Kind: KindPost, ID: "new-post", Time: now, Tags: []string{"travel", "food"}, Fields: []Field{
+		{Name: "text", Text: "Sunset dinner #travel #food", Boost: 1},
+	}})
+	return index
+}
+
+func hitIDs(hits []Hit) []string {
+	var ids []string
+	for _, hit := range hits {
+		ids = append(ids, hit.Kind+":"+hit.ID)
+	}
+	return ids
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	index := testIndex()
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{name: "Full name", query: Query{Text: "john doe", Kinds: []string{KindUser}}, want: []string{"user:john"}},
+		{name: "Username", query: Query{Text: "john_doe"}, want: []string{"user:john"}},
+		{name: "Prefix ranks the exact word first", query: Query{Text: "john", Kinds: []string{KindUser}}, want: []string{"user:john", "user:jane"}},
+		{name: "Typo", query: Query{Text: "sarajvo"}, want: []string{"user:john"}},
+		{name: "Short words need to be written correctly", query: Query{Text: "jon", Kinds: []string{KindUser}}, want: nil},
+		{name: "Newer posts first when the scores are equal", query: Query{Text: "sunset", Kinds: []string{KindPost}}, want: []string{"post:new-post", "post:old-post"}},
+		{name: "Hashtags by the number of posts", query: Query{Text: "#trav", Kinds: []string{KindHashtag}}, want: []string{"hashtag:travel"}},
+		{name: "Limit", query: Query{Text: "sunset", Limit: 1}, want: []string{"post:new-post"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, _ := index.Search(tt.query)
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query.Text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexReplaceAndRemove(t *testing.T) {
+	index := testIndex()
+
+	//the edited post is found only by the new text
+	index.Index(Document{Kind: KindPost, ID: "old-post", Fields: []Field{{Name: "text", Text: "Bridge in Mostar", Boost: 1}}})
+	if hits, _ := index.Search(Query{Text: "sunset", Kinds: []string{KindPost}}); !reflect.DeepEqual(hitIDs(hits), []string{"post:new-post"}) {
+		t.Errorf("Search() after the edit = %v", hitIDs(hits))
+	}
+
+	//the hashtag disappears with its last post
+	index.Remove(KindPost, "new-post")
+	if hits, _ := index.Search(Query{Text: "travel", Kinds: []string{KindHashtag}}); len(hits) != 0 {
+		t.Errorf("Search() after the removal = %v, want no hashtags", hitIDs(hits))
+	}
+}
+
+func TestHitMatchesWithout(t *testing.T) {
+	hits, _ := testIndex().Search(Query{Text: "john photographer", Kinds: []string{KindUser}})
+	if len(hits) != 1 {
+		t.Fatalf("Search() = %v, want one user", hitIDs(hits))
+	}
+	if hits[0].MatchesWithout("description") {
+		t.Error("MatchesWithout(description) = true, want false")
+	}
+	if !hits[0].MatchesWithout("username") {
+		t.Error("MatchesWithout(username) = false, want true")
+	}
+}
@@ -0,0 +1,70 @@
+// Package search finds the users, the posts and the hashtags by their text.
+package search
+
+import "time"
+
+// kinds of the documents in the index
+const (
+	KindUser    = "user"
+	KindPost    = "post"
+	KindHashtag = "hashtag"
+)
+
+// Field is one searchable text of the document, the matches in the fields with the bigger boost rank higher
+type Field struct {
+	Name  string
+	Text  string
+	Boost float64
+}
+
+// Document is the user or the post as it is kept in the index
+type Document struct {
+	Kind   string
+	ID     string
+	Fields []Field
+	// the hashtags of the post, the index counts the posts of every hashtag
+	Tags []string
+	// the newer documents rank higher when the scores are equal
+	Time time.Time
+}
+
+type Query struct {
+	Text string
+	// the kinds of the documents to search, all kinds are searched when it is empty
+	Kinds []string
+	Limit int
+}
+
+// Hit is the found document, the hits of the hashtags have the tag as the id
+type Hit struct {
+	Kind  string
+	ID    string
+	Score float64
+	// the number of the posts that use the hashtag
+	Count int
+	// the fields that matched every word of the query, in the order of the words
+	TermFields [][]string
+}
+
+// MatchesWithout checks whether every word of the query also matched outside of the field,
+// so that the hit can be kept when the viewer is not allowed to see that field
+func (hit Hit) MatchesWithout(field string) bool {
+	for _, fields := range hit.TermFields {
+		found := false
+		for _, name := range fields {
+			found = found || name != field
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchIndex keeps the documents and ranks them for the query. The hits are not filtered,
+// the blocks and the privacy settings are applied on the results by the caller.
+type SearchIndex interface {
+	Index(doc Document) error
+	Remove(kind string, id string) error
+	Search(query Query) ([]Hit, error)
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits the text into lowercase words. The words joined with the underscore (usernames)
+// are kept whole and split into their parts, so that both "john_doe" and "doe" find them.
+func Tokenize(text string) []string {
+	var tokens []string
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+	for _, word := range words {
+		word = strings.Trim(word, "_")
+		if word == "" {
+			continue
+		}
+		tokens = append(tokens, word)
+		if strings.Contains(word, "_") {
+			for _, part := range strings.Split(word, "_") {
+				if part != "" {
+					tokens = append(tokens, part)
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// maxTypos is the number of the typos allowed in the word, the short words must be written correctly
+func maxTypos(word []rune) int {
+	switch {
+	case len(word) >= 8:
+		return 2
+	case len(word) >= 4:
+		return 1
+	}
+	return 0
+}
+
+// editDistance is the Levenshtein distance of the words, it stops counting when the distance is bigger than max
+func editDistance(a []rune, b []rune, max int) int {
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return max + 1
+	}
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if current[j] < rowMin {
+				rowMin = current[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	smallest := values[0]
+	for _, value := range values[1:] {
+		if value < smallest {
+			smallest = value
+		}
+	}
+	return smallest
+}
+
+// matchQuality tells how well the indexed term matches the word from the query: 1 for the same word,
+// less for the word that the term starts with (autocomplete), and the least for the word with typos
+func matchQuality(word []rune, term string) float64 {
+	termRunes := []rune(term)
+	if string(word) == term {
+		return 1
+	}
+	if len(word) >= 2 && strings.HasPrefix(term, string(word)) {
+		return 0.6 + 0.3*float64(len(word))/float64(len(termRunes))
+	}
+	if typos := maxTypos(word); typos > 0 {
+		if distance := editDistance(word, termRunes, typos); distance <= typos {
+			return 0.6 - 0.15*float64(distance)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+// Package textdiff compares two versions of a text word by word.
+package textdiff
+
+import "regexp"
+
+// types of the parts of the diff
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Part is a run of words that are kept, added or removed
+type Part struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+var tokenPattern = regexp.MustCompile(`\s+|\S+`)
+
+// MaxTokens limits the size of the table used to compare the changed middle of the texts,
+// the longer changes are shown as the old words removed and the new words added
+const MaxTokens = 2000
+
+// Diff returns the parts that turn the old text into the new text, the whitespace is kept so that the parts can be joined back
+func Diff(oldText string, newText string) []Part {
+	a := tokenPattern.FindAllString(oldText, -1)
+	b := tokenPattern.FindAllString(newText, -1)
+
+	//merge the neighbouring tokens of the same type
+	var parts []Part
+	add := func(partType string, token string) {
+		if n := len(parts); n > 0 && parts[n-1].Type == partType {
+			parts[n-1].Text += token
+			return
+		}
+		parts = append(parts, Part{Type: partType, Text: token})
+	}
+
+	//the common beginning and the common end are not compared
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, token := range a[:prefix] {
+		add(Equal, token)
+	}
+	diffTokens(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], add)
+	for _, token := range a[len(a)-suffix:] {
+		add(Equal, token)
+	}
+	return parts
+}
+
+// diffTokens adds the parts that turn the tokens a into the tokens b
+func diffTokens(a []string, b []string, add func(partType string, token string)) {
+	if len(a)+len(b) > MaxTokens {
+		for _, token := range a {
+			add(Delete, token)
+		}
+		for _, token := range b {
+			add(Insert, token)
+		}
+		return
+	}
+
+	//lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	//walk the table
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(Equal, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(Delete, a[i])
+			i++
+		default:
+			add(Insert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(Delete, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(Insert, b[j])
+	}
+}
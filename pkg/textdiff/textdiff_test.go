@@ -0,0 +1,45 @@
+package textdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    []Part
+	}{
+		{name: "Same text", oldText: "hello world", newText: "hello world", want: []Part{{Equal, "hello world"}}},
+		{name: "Word is replaced", oldText: "I love cats", newText: "I love dogs", want: []Part{{Equal, "I love "}, {Delete, "cats"}, {Insert, "dogs"}}},
+		{name: "Words are added", oldText: "Great day", newText: "Great day at the beach", want: []Part{{Equal, "Great day"}, {Insert, " at the beach"}}},
+		{name: "Text is removed", oldText: "old text", newText: "", want: []Part{{Delete, "old text"}}},
+		{name: "Both texts are empty", oldText: "", newText: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.oldText, tt.newText); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLongChange(t *testing.T) {
+	oldText := "Start " + strings.Repeat("a ", MaxTokens) + "end"
+	newText := "Start " + strings.Repeat("b ", MaxTokens) + "end"
+
+	//the change is too long to be compared word by word, so it is shown as removed and added
+	want := []Part{
+		{Equal, "Start "},
+		{Delete, strings.TrimSpace(strings.Repeat("a ", MaxTokens))},
+		{Insert, strings.TrimSpace(strings.Repeat("b ", MaxTokens))},
+		{Equal, " end"},
+	}
+	if got := Diff(oldText, newText); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
@@ -6,6 +6,13 @@ type WsServer struct {
 	unregister chan *Client
 	broadcast  chan []byte
 	rooms      map[*Room]bool
+	// the clients subscribed to the live counts of every post
+	subscriptions map[string]map[*Client]bool
+	subscribers   postSubscribers
+	subscribe     chan postSubscription
+	unsubscribe   chan postSubscription
+	separate      chan separatedUsers
+	feed          chan feedEvent
 }
 
 // NewWebsocketServer creates a new WsServer type
@@ -16,6 +23,13 @@ func NewWebsocketServer() *WsServer {
 		unregister: make(chan *Client),
 		broadcast:  make(chan []byte),
 		rooms:      make(map[*Room]bool),
+
+		subscriptions: make(map[string]map[*Client]bool),
+		subscribers:   postSubscribers{counts: make(map[string]int)},
+		subscribe:     make(chan postSubscription),
+		unsubscribe:   make(chan postSubscription),
+		separate:      make(chan separatedUsers),
+		feed:          make(chan feedEvent, 256),
 	}
 }
 
@@ -32,6 +46,18 @@ func (server *WsServer) Run() {
 
 		case message := <-server.broadcast:
 			server.broadcastToClients(message)
+
+		case subscription := <-server.subscribe:
+			server.subscribeClient(subscription)
+
+		case subscription := <-server.unsubscribe:
+			server.unsubscribeClient(subscription)
+
+		case users := <-server.separate:
+			server.separateUsers(users)
+
+		case event := <-server.feed:
+			server.sendFeedEvent(event)
 		}
 
 	}
@@ -43,6 +69,9 @@ func (server *WsServer) registerClient(client *Client) {
 
 func (server *WsServer) unregisterClient(client *Client) {
 	delete(server.clients, client)
+	for postID := range client.posts {
+		server.unsubscribeClient(postSubscription{client: client, postID: postID})
+	}
 }
 
 func (server *WsServer) broadcastToClients(message []byte) {
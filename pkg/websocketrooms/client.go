@@ -1,14 +1,17 @@
 package websocketrooms
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/dika-bosnjak/social-media-app/pkg/contentfilter"
 	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
 	"github.com/dika-bosnjak/social-media-app/pkg/models"
+	"github.com/dika-bosnjak/social-media-app/pkg/workers"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -47,6 +50,8 @@ type Client struct {
 	send     chan []byte
 	ID       string `json:"id"`
 	rooms    map[*Room]bool
+	// the posts whose live counts the client gets with the ids of their authors, it is changed only by the WsServer
+	posts map[string]string
 }
 
 func newClient(conn *websocket.Conn, wsServer *WsServer, userID string) *Client {
@@ -56,6 +61,7 @@ func newClient(conn *websocket.Conn, wsServer *WsServer, userID string) *Client
 		wsServer: wsServer,
 		send:     make(chan []byte, 256),
 		rooms:    make(map[*Room]bool),
+		posts:    make(map[string]string),
 	}
 
 }
@@ -165,30 +171,80 @@ func (client *Client) handleNewMessage(jsonMessage []byte) {
 	switch message.Action {
 	case SendMessageAction:
 		roomID := message.Target
-		if room := client.wsServer.findRoomByID(roomID); room != nil {
-			room.broadcast <- &message
+
+		//check whether the sender is in the room and can still message the other user
+		receiverID, allowed := canSendToRoom(initializers.DB, message.Sender.ID, roomID)
+		if !allowed {
+			return
+		}
+
+		//check the text with the content filter
+		result := initializers.ContentFilter.Check(message.Sender.ID, contentfilter.KindMessage, message.Message)
+		if result.Verdict == contentfilter.Reject {
+			client.send <- (&Message{Action: MessageRejectedAction, Message: result.Reason, Target: roomID, Time: message.Time}).encode()
+			return
+		}
+
+		//the id is sent to the clients so that the message can be reported
+		message.ID = uuid.New().String()
+		models.SendMessage(initializers.DB, message.ID, roomID, message.Sender.ID, string(message.Message), time.Now())
+
+		//the message held by the filter is not delivered until a moderator reviews it
+		if result.Verdict == contentfilter.Hold {
+			models.SetMessageHidden(initializers.DB, message.ID, true)
+			models.HoldForReview(initializers.DB, models.ReportTargetMessage, message.ID, message.Sender.ID, "/chat", result.Reason)
+			client.send <- (&Message{ID: message.ID, Action: MessageHeldAction, Message: result.Reason, Target: roomID, Time: message.Time}).encode()
+			return
+		}
+
+		//resolve the mentions so that the clients can render the links
+		message.Mentions, _ = models.ResolveMentions(initializers.DB, message.Message)
+
+		//attach the cached preview of the link, and fetch it in the background when it is not cached yet
+		message.LinkPreview = models.LinkPreviewOf(initializers.DB, message.Message)
+		if message.LinkPreview == nil {
+			workers.LinkPreviews.Enqueue(message.Message)
 		}
 
-		// var messageDB = models.Message{ID: uuid.New().String(), RoomID: roomID, Sender: message.Sender.ID, Message: string(message.Message), CreatedAt: time.Now()}
-		// initializers.DB.Save(&messageDB)
-		models.SendMessage(initializers.DB, uuid.New().String(), roomID, message.Sender.ID, string(message.Message), time.Now())
-
-		chatUser1, chatUser2 := models.FindChatRoomUsers(initializers.DB, roomID)
-		// initializers.DB.Table("rooms").Select("user1_id").Where("id = ?", roomID).Find(&chatUser1)
-		// initializers.DB.Table("rooms").Select("user2_id").Where("id = ?", roomID).Find(&chatUser2)
-		if message.Sender.ID == chatUser1 {
-			models.SaveNotification(initializers.DB, chatUser2, message.Sender.ID, "sent you a message", "/chat")
-		} else {
-			models.SaveNotification(initializers.DB, chatUser1, message.Sender.ID, "sent you a message", "/chat")
+		if room := client.wsServer.findRoomByID(roomID); room != nil {
+			room.broadcast <- &message
 		}
+		//only the other user in the room can see the message and the mentions, and that user is already notified about the message
+		models.SaveNotification(initializers.DB, receiverID, message.Sender.ID, "sent you a message", "/chat")
 	case LeaveRoomAction:
 		client.handleLeaveRoomMessage(message)
 
 	case JoinRoomPrivateAction:
 		client.handleJoinRoomPrivateMessage(message)
+
+	case SubscribePostAction:
+		client.handleSubscribePostMessage(message)
+
+	case UnsubscribePostAction:
+		client.wsServer.unsubscribe <- postSubscription{client: client, postID: message.Target}
 	}
 }
 
+// canSendToRoom returns the other user from the chat room and whether the sender can message him
+func canSendToRoom(db *sql.DB, senderID string, roomID string) (string, bool) {
+	chatUser1, chatUser2 := models.FindChatRoomUsers(db, roomID)
+	if chatUser1 == "" || chatUser2 == "" {
+		return "", false
+	}
+
+	var receiverID string
+	switch senderID {
+	case chatUser1:
+		receiverID = chatUser2
+	case chatUser2:
+		receiverID = chatUser1
+	default:
+		return "", false
+	}
+
+	return receiverID, models.CanInteract(db, senderID, receiverID, models.ActionMessage)
+}
+
 func (client *Client) handleLeaveRoomMessage(message Message) {
 	room := client.wsServer.findRoomByID(message.Target)
 	if room == nil {
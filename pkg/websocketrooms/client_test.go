@@ -0,0 +1,50 @@
+package websocketrooms
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCanSendToRoom(t *testing.T) {
+	const (
+		roomID = "80869e94-124c-4f17-96f7-f5283df7d909"
+		user1  = "40469e94-124c-4f17-96f7-f5283df7d707"
+		user2  = "50569e94-124c-4f17-96f7-f5283df7d505"
+	)
+
+	tests := []struct {
+		name         string
+		senderID     string
+		blocked      bool
+		wantReceiver string
+		wantAllowed  bool
+	}{
+		{name: "Sender is not in the room", senderID: "stranger", wantAllowed: false},
+		{name: "Sender is blocked by the receiver", senderID: user1, blocked: true, wantReceiver: user2, wantAllowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+
+			mock.ExpectQuery("FROM rooms").WithArgs(roomID).
+				WillReturnRows(sqlmock.NewRows([]string{"user1_id", "user2_id"}).AddRow(user1, user2))
+			if tt.blocked {
+				mock.ExpectQuery("FROM blocks").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("70769e94-124c-4f17-96f7-f5283df7d808"))
+			}
+
+			receiver, allowed := canSendToRoom(sqlDB, tt.senderID, roomID)
+			if receiver != tt.wantReceiver || allowed != tt.wantAllowed {
+				t.Errorf("canSendToRoom() = %v, %v, want %v, %v", receiver, allowed, tt.wantReceiver, tt.wantAllowed)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("canSendToRoom() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package websocketrooms
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/initializers"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+// Hub is the websocket server that pushes the feed events to the online users, it is nil until the routes start it
+var Hub *WsServer
+
+// maxPostSubscriptions is the number of the posts one client can follow at the same time
+const maxPostSubscriptions = 100
+
+type postSubscription struct {
+	client  *Client
+	postID  string
+	ownerID string
+}
+
+// separatedUsers are the users who are no longer friends, or one of them blocked the other
+type separatedUsers struct {
+	userID      string
+	otherUserID string
+}
+
+// postSubscribers mirrors the subscribed posts of the server, so that the controllers can check it without the Run loop
+type postSubscribers struct {
+	sync.RWMutex
+	counts map[string]int
+}
+
+// feedEvent is sent to the clients subscribed to the post, or to the online users when the users are given
+type feedEvent struct {
+	postID  string
+	userIDs map[string]bool
+	message []byte
+}
+
+// PostPublished lets the online friends of the author know that there is a new post in their feed,
+// the friends who muted the author are skipped
+func (server *WsServer) PostPublished(db *sql.DB, post models.Post) {
+	if server == nil || !post.IsLive() {
+		return
+	}
+	muting, err := models.GetMutingUsersID(db, post.UserID)
+	if err != nil {
+		log.Println("live feed: failed to get the muting users:", err)
+		return
+	}
+	skipped := make(map[string]bool)
+	for _, userID := range muting {
+		skipped[userID] = true
+	}
+	userIDs := make(map[string]bool)
+	for _, friendID := range models.GetFriendsIDs(db, post.UserID) {
+		if !skipped[friendID] {
+			userIDs[friendID] = true
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	message := &Message{Action: NewPostAction, Target: post.ID, UserID: post.UserID, Time: time.Now()}
+	server.sendFeed(feedEvent{userIDs: userIDs, message: message.encode()})
+}
+
+// PostCountsChanged sends the new likes, reactions and comment count of the post to the subscribed clients,
+// the counts are not read when nobody is subscribed to the post
+func (server *WsServer) PostCountsChanged(db *sql.DB, postID string) {
+	if server == nil || !server.hasSubscribers(postID) {
+		return
+	}
+	counts := PostCounts{
+		Likes:        models.LikeCount(db, postID),
+		Reactions:    models.ReactionCounts(db, models.ReactionTargetPost, postID),
+		CommentCount: models.GetNumberOfComments(db, postID),
+	}
+	message := &Message{Action: PostCountsAction, Target: postID, Counts: &counts, Time: time.Now()}
+	server.sendFeed(feedEvent{postID: postID, message: message.encode()})
+}
+
+// UsersSeparated ends the subscriptions of the users to the posts of each other, it is called when the friendship
+// is deleted or one of the users blocks the other. The client subscribes again to the posts the user can still see.
+func (server *WsServer) UsersSeparated(userID, otherUserID string) {
+	if server == nil {
+		return
+	}
+	server.separate <- separatedUsers{userID: userID, otherUserID: otherUserID}
+}
+
+// sendFeed queues the event without waiting, the event is dropped when the queue is full so that the request is not blocked
+func (server *WsServer) sendFeed(event feedEvent) {
+	select {
+	case server.feed <- event:
+	default:
+		log.Println("live feed: the queue is full, the event is dropped")
+	}
+}
+
+func (server *WsServer) hasSubscribers(postID string) bool {
+	server.subscribers.RLock()
+	defer server.subscribers.RUnlock()
+	return server.subscribers.counts[postID] > 0
+}
+
+func (server *WsServer) subscribeClient(subscription postSubscription) {
+	client := subscription.client
+	if _, online := server.clients[client]; !online || len(client.posts) >= maxPostSubscriptions {
+		return
+	}
+	if server.subscriptions[subscription.postID] == nil {
+		server.subscriptions[subscription.postID] = make(map[*Client]bool)
+	}
+	server.subscriptions[subscription.postID][client] = true
+	client.posts[subscription.postID] = subscription.ownerID
+	server.countSubscribers(subscription.postID)
+}
+
+func (server *WsServer) unsubscribeClient(subscription postSubscription) {
+	delete(subscription.client.posts, subscription.postID)
+	delete(server.subscriptions[subscription.postID], subscription.client)
+	if len(server.subscriptions[subscription.postID]) == 0 {
+		delete(server.subscriptions, subscription.postID)
+	}
+	server.countSubscribers(subscription.postID)
+}
+
+// countSubscribers copies the number of the subscribed clients of the post to the mirror read by hasSubscribers
+func (server *WsServer) countSubscribers(postID string) {
+	server.subscribers.Lock()
+	defer server.subscribers.Unlock()
+	if count := len(server.subscriptions[postID]); count > 0 {
+		server.subscribers.counts[postID] = count
+	} else {
+		delete(server.subscribers.counts, postID)
+	}
+}
+
+// separateUsers unsubscribes the clients of both users from the posts of the other user
+func (server *WsServer) separateUsers(users separatedUsers) {
+	for client := range server.clients {
+		var ownerID string
+		switch client.ID {
+		case users.userID:
+			ownerID = users.otherUserID
+		case users.otherUserID:
+			ownerID = users.userID
+		default:
+			continue
+		}
+		for postID, postOwnerID := range client.posts {
+			if postOwnerID == ownerID {
+				server.unsubscribeClient(postSubscription{client: client, postID: postID})
+			}
+		}
+	}
+}
+
+// sendFeedEvent delivers the event without waiting, the client that is too slow misses the update
+func (server *WsServer) sendFeedEvent(event feedEvent) {
+	var clients []*Client
+	if event.userIDs != nil {
+		for client := range server.clients {
+			if event.userIDs[client.ID] {
+				clients = append(clients, client)
+			}
+		}
+	} else {
+		for client := range server.subscriptions[event.postID] {
+			clients = append(clients, client)
+		}
+	}
+
+	for _, client := range clients {
+		select {
+		case client.send <- event.message:
+		default:
+		}
+	}
+}
+
+// handleSubscribePostMessage subscribes the client to the post that the user can see
+func (client *Client) handleSubscribePostMessage(message Message) {
+	if client.ID == "" {
+		return
+	}
+	post, err := models.GetPostByID(initializers.DB, message.Target)
+	if err != nil || !models.CanViewPost(initializers.DB, client.ID, post) {
+		return
+	}
+	client.wsServer.subscribe <- postSubscription{client: client, postID: post.ID, ownerID: post.UserID}
+}
@@ -0,0 +1,107 @@
+package websocketrooms
+
+import "testing"
+
+func testClient(server *WsServer, userID string) *Client {
+	client := &Client{ID: userID, wsServer: server, send: make(chan []byte, 1), rooms: make(map[*Room]bool), posts: make(map[string]string)}
+	server.registerClient(client)
+	return client
+}
+
+func received(client *Client) bool {
+	select {
+	case <-client.send:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPostSubscriptions(t *testing.T) {
+	server := NewWebsocketServer()
+	viewer := testClient(server, "viewer")
+	other := testClient(server, "other")
+
+	//only the subscribed client gets the counts of the post
+	server.subscribeClient(postSubscription{client: viewer, postID: "post-id"})
+	server.sendFeedEvent(feedEvent{postID: "post-id", message: []byte("counts")})
+	if !received(viewer) || received(other) {
+		t.Error("the counts should be sent only to the subscribed client")
+	}
+
+	//the full send buffer does not block the server
+	server.sendFeedEvent(feedEvent{postID: "post-id", message: []byte("counts")})
+	server.sendFeedEvent(feedEvent{postID: "post-id", message: []byte("counts")})
+	received(viewer)
+
+	//the subscriptions end when the client leaves
+	server.unregisterClient(viewer)
+	if len(server.subscriptions) != 0 || len(viewer.posts) != 0 {
+		t.Errorf("the subscriptions should be removed, got %v", server.subscriptions)
+	}
+
+	//the client that is not online can not subscribe
+	server.subscribeClient(postSubscription{client: viewer, postID: "post-id"})
+	if len(server.subscriptions) != 0 {
+		t.Error("the offline client should not be subscribed")
+	}
+}
+
+func TestNewPostEventIsSentToGivenUsers(t *testing.T) {
+	server := NewWebsocketServer()
+	friend := testClient(server, "friend")
+	stranger := testClient(server, "stranger")
+
+	server.sendFeedEvent(feedEvent{userIDs: map[string]bool{"friend": true}, message: []byte("new post")})
+	if !received(friend) || received(stranger) {
+		t.Error("the new post should be sent only to the friends")
+	}
+}
+
+func TestSubscribersAreCounted(t *testing.T) {
+	server := NewWebsocketServer()
+	viewer := testClient(server, "viewer")
+
+	if server.hasSubscribers("post-id") {
+		t.Error("the post should not have subscribers")
+	}
+	server.subscribeClient(postSubscription{client: viewer, postID: "post-id", ownerID: "author"})
+	if !server.hasSubscribers("post-id") {
+		t.Error("the post should have the subscriber")
+	}
+	server.unregisterClient(viewer)
+	if server.hasSubscribers("post-id") || len(server.subscribers.counts) != 0 {
+		t.Errorf("the subscribers should be removed, got %v", server.subscribers.counts)
+	}
+}
+
+func TestFullFeedQueueDoesNotBlock(t *testing.T) {
+	server := NewWebsocketServer()
+	for i := 0; i <= cap(server.feed); i++ {
+		server.sendFeed(feedEvent{postID: "post-id", message: []byte("counts")})
+	}
+	if len(server.feed) != cap(server.feed) {
+		t.Errorf("the queue should be full, got %d events", len(server.feed))
+	}
+}
+
+func TestSeparatedUsersAreUnsubscribed(t *testing.T) {
+	server := NewWebsocketServer()
+	user := testClient(server, "user")
+	former := testClient(server, "former")
+	other := testClient(server, "other")
+
+	server.subscribeClient(postSubscription{client: user, postID: "former-post", ownerID: "former"})
+	server.subscribeClient(postSubscription{client: user, postID: "other-post", ownerID: "other"})
+	server.subscribeClient(postSubscription{client: former, postID: "user-post", ownerID: "user"})
+	server.subscribeClient(postSubscription{client: other, postID: "former-post", ownerID: "former"})
+
+	//only the subscriptions of the two users to the posts of each other end
+	server.separateUsers(separatedUsers{userID: "user", otherUserID: "former"})
+	if _, found := user.posts["former-post"]; found || len(former.posts) != 0 {
+		t.Error("the users should be unsubscribed from the posts of each other")
+	}
+	if _, found := user.posts["other-post"]; !found || len(other.posts) != 1 || !server.hasSubscribers("former-post") {
+		t.Error("the other subscriptions should be kept")
+	}
+}
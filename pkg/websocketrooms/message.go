@@ -4,18 +4,52 @@ import (
 	"encoding/json"
 	"log"
 	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
 )
 
 const SendMessageAction = "send-message"
 const JoinRoomPrivateAction = "join-room"
 const LeaveRoomAction = "leave-room"
 
+// sent back only to the sender when the content filter stops the message
+const MessageRejectedAction = "message-rejected"
+const MessageHeldAction = "message-held"
+
+// Live feed protocol. The client subscribes to the posts it is showing with {"action": "subscribe-post", "target": "<post id>"}
+// and stops with {"action": "unsubscribe-post", "target": "<post id>"}, the subscriptions end when the connection closes.
+// The server sends {"action": "post-counts", "target": "<post id>", "counts": {...}} when the likes, the reactions
+// or the comments of a subscribed post change, and {"action": "new-post", "target": "<post id>", "user_id": "<author id>"}
+// to the online friends of the author when a new post is published, so that the client can reload the feed.
+// The new post is sent when the author publishes it, when the scheduler publishes it and when a moderator approves
+// the held post; a post changed to be visible to more users is not sent. The subscriptions to the posts of the user
+// end when the friendship is deleted or one of the users blocks the other, and the events that do not fit in the
+// queue are dropped, so the client should reload the counts when it shows the post again.
+const SubscribePostAction = "subscribe-post"
+const UnsubscribePostAction = "unsubscribe-post"
+const PostCountsAction = "post-counts"
+const NewPostAction = "new-post"
+
 type Message struct {
-	Action  string    `json:"action"`
-	Message string    `json:"message"`
-	Target  string    `json:"target"`
-	Sender  *Client   `json:"sender"`
-	Time    time.Time `json:"created_at"`
+	ID       string           `json:"id"`
+	Action   string           `json:"action"`
+	Message  string           `json:"message"`
+	Target   string           `json:"target"`
+	Sender   *Client          `json:"sender"`
+	Mentions []models.Mention `json:"mentions,omitempty"`
+	// the preview is sent when the link was already fetched, otherwise it is shown after the chat is opened again
+	LinkPreview *models.LinkPreview `json:"link_preview,omitempty"`
+	// the author of the new post, and the counts of the subscribed post
+	UserID string      `json:"user_id,omitempty"`
+	Counts *PostCounts `json:"counts,omitempty"`
+	Time   time.Time   `json:"created_at"`
+}
+
+// PostCounts are the live counts of the post, the same as in the post info
+type PostCounts struct {
+	Likes        int            `json:"likes"`
+	Reactions    map[string]int `json:"reactions"`
+	CommentCount int            `json:"comment_count"`
 }
 
 func (message *Message) encode() []byte {
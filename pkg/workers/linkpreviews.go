@@ -0,0 +1,82 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/linkpreview"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+// LinkPreviews fetches the previews of the links written in the posts and the chat, it is nil until the server starts it
+var LinkPreviews *LinkPreviewer
+
+// LinkPreviewer fetches the previews in the background, so that saving a post or a message does not wait for other sites.
+// The previews are kept in the cache table and fetched again when they get old.
+type LinkPreviewer struct {
+	db      *sql.DB
+	fetcher linkpreview.Fetcher
+	queue   chan string
+	timeout time.Duration
+	maxAge  time.Duration
+	now     func() time.Time
+}
+
+func NewLinkPreviewer(db *sql.DB, fetcher linkpreview.Fetcher, queueSize int, timeout time.Duration, maxAge time.Duration) *LinkPreviewer {
+	return &LinkPreviewer{
+		db:      db,
+		fetcher: fetcher,
+		queue:   make(chan string, queueSize),
+		timeout: timeout,
+		maxAge:  maxAge,
+		now:     time.Now,
+	}
+}
+
+// Enqueue adds the links from the text to the queue. When the queue is full the links are skipped,
+// the post or the message is shown without the preview then.
+func (previewer *LinkPreviewer) Enqueue(text string) {
+	if previewer == nil {
+		return
+	}
+	for _, url := range linkpreview.ExtractURLs(text) {
+		select {
+		case previewer.queue <- url:
+		default:
+			log.Println("link previews: queue is full, skipping", url)
+		}
+	}
+}
+
+// Run starts the workers that fetch the queued links, it never returns
+func (previewer *LinkPreviewer) Run(workers int) {
+	for i := 1; i < workers; i++ {
+		go previewer.work()
+	}
+	previewer.work()
+}
+
+func (previewer *LinkPreviewer) work() {
+	for url := range previewer.queue {
+		previewer.preview(url)
+	}
+}
+
+func (previewer *LinkPreviewer) preview(url string) {
+
+	//the fresh preview in the cache is not fetched again
+	now := previewer.now()
+	if cached, err := models.GetLinkPreview(previewer.db, url); err == nil && now.Sub(cached.FetchedAt) < previewer.maxAge {
+		return
+	}
+
+	//the link without a preview is saved too, so it is not fetched again until it gets old
+	ctx, cancel := context.WithTimeout(context.Background(), previewer.timeout)
+	defer cancel()
+	preview, err := previewer.fetcher.Fetch(ctx, url)
+	if err := models.SaveLinkPreview(previewer.db, url, preview, err != nil, now); err != nil {
+		log.Println("link previews: failed to save the preview of", url, err)
+	}
+}
@@ -0,0 +1,70 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/linkpreview"
+)
+
+// stubFetcher stands in for the sites in the tests
+type stubFetcher map[string]linkpreview.Preview
+
+func (fetcher stubFetcher) Fetch(ctx context.Context, url string) (linkpreview.Preview, error) {
+	preview, found := fetcher[url]
+	if !found {
+		return preview, linkpreview.ErrNoPreview
+	}
+	return preview, nil
+}
+
+func TestLinkPreviewerCachesFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	previewer := NewLinkPreviewer(db, stubFetcher{"https://example.com": {Title: "Example"}}, 10, time.Second, time.Hour)
+	previewer.now = func() time.Time { return now }
+
+	//the link that is not cached is fetched and saved
+	mock.ExpectQuery("FROM link_previews").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO link_previews").
+		WithArgs(sqlmock.AnyArg(), "https://example.com", "Example", "", "", "", false, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	previewer.preview("https://example.com")
+
+	//the link without a preview is saved as failed
+	mock.ExpectQuery("FROM link_previews").WillReturnError(errors.New("not found"))
+	mock.ExpectExec("INSERT INTO link_previews").
+		WithArgs(sqlmock.AnyArg(), "https://example.org", "", "", "", "", true, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	previewer.preview("https://example.org")
+
+	//the fresh preview is not fetched again
+	mock.ExpectQuery("FROM link_previews").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "title", "description", "image_url", "site_name", "failed", "fetched_at"}).
+			AddRow("id", "https://example.com", "Example", "", "", "", false, now.Add(-time.Minute)))
+	previewer.preview("https://example.com")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLinkPreviewerSkipsWhenQueueIsFull(t *testing.T) {
+	previewer := NewLinkPreviewer(nil, stubFetcher{}, 1, time.Second, time.Hour)
+	previewer.Enqueue("https://example.com https://example.org")
+	if len(previewer.queue) != 1 {
+		t.Errorf("queue length = %d, want 1", len(previewer.queue))
+	}
+
+	//the previewer that is not started ignores the links
+	var notStarted *LinkPreviewer
+	notStarted.Enqueue("https://example.com")
+}
@@ -0,0 +1,63 @@
+// Package workers runs the background jobs of the server.
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+// PublishedPosts is told about the posts published by the scheduler, main sets it to the websocket server
+// so that the online friends get the new post. It is nil when nobody listens.
+var PublishedPosts interface {
+	PostPublished(db *sql.DB, post models.Post)
+}
+
+// RunPostScheduler publishes the scheduled posts when their time comes. The schedule is kept in the database,
+// so the posts that were due while the server was down are published on the first run after the restart.
+func RunPostScheduler(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		publishDuePosts(db, time.Now())
+		<-ticker.C
+	}
+}
+
+func publishDuePosts(db *sql.DB, now time.Time) {
+
+	//get the posts whose time has come
+	posts, err := models.GetDueScheduledPosts(db, now)
+	if err != nil {
+		log.Println("scheduler: failed to get the scheduled posts:", err)
+		return
+	}
+
+	for _, post := range posts {
+
+		//publish the post, the post published in the meantime by its author is skipped
+		published, err := models.PublishPost(db, post.ID, now)
+		if err != nil {
+			log.Println("scheduler: failed to publish the post", post.ID, err)
+			continue
+		}
+		if !published {
+			continue
+		}
+
+		//write the post to the timelines, and notify the friends and the mentioned users at the moment of publishing
+		Timelines.PostPublished(post.ID)
+		post.Status = models.PostStatusPublished
+		post.PublishAt = nil
+		post.CreatedAt = now
+		if !post.Hidden {
+			models.NotifyPublishedPost(db, post)
+		}
+		if PublishedPosts != nil {
+			PublishedPosts.PostPublished(db, post)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+func TestPublishDuePostsSkipsPublishedPosts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM posts").WithArgs(models.PostStatusScheduled, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}).
+			AddRow("post-id", "", "Scheduled post", "user-id", "", false, models.PostStatusScheduled, now, nil, nil, now, now))
+
+	//the author published the post in the meantime, so nothing is updated and nobody is notified
+	mock.ExpectExec("UPDATE posts").WithArgs(models.PostStatusPublished, now, "post-id", models.PostStatusPublished).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	publishDuePosts(db, now)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
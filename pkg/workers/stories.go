@@ -0,0 +1,38 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+// RunStorySweeper removes the stories after they expire. The stories are hidden as soon as they expire,
+// the sweeper only cleans up the database, so it can run less often than the stories expire.
+func RunStorySweeper(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleteExpiredStories(db, time.Now())
+		<-ticker.C
+	}
+}
+
+func deleteExpiredStories(db *sql.DB, now time.Time) {
+
+	//get the stories whose time has passed
+	stories, err := models.GetExpiredStories(db, now)
+	if err != nil {
+		log.Println("sweeper: failed to get the expired stories:", err)
+		return
+	}
+
+	//delete the stories with their views, the photos are only linked by the url, so there are no files to remove
+	for _, story := range stories {
+		if err := models.DeleteStory(db, story.ID); err != nil {
+			log.Println("sweeper: failed to delete the story", story.ID, err)
+		}
+	}
+}
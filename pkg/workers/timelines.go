@@ -0,0 +1,219 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+// Timelines writes the published posts and the friendship changes to the timelines, it is nil until the server starts it
+var Timelines *TimelineFanout
+
+// kinds of the timeline jobs
+const (
+	timelinePostPublished     = "post published"
+	timelineFriendshipAdded   = "friendship added"
+	timelineFriendshipRemoved = "friendship removed"
+)
+
+type timelineJob struct {
+	kind    string
+	postID  string
+	user1ID string
+	user2ID string
+}
+
+// TimelineFanout fills the timelines in the background, so that publishing a post does not wait for the writes
+// to the timelines of all friends. The authors with more friends than the celebrity limit are not fanned out,
+// their posts are read when the feed is built.
+type TimelineFanout struct {
+	db               *sql.DB
+	queue            chan timelineJob
+	celebrityFriends int
+	now              func() time.Time
+}
+
+func NewTimelineFanout(db *sql.DB, queueSize int, celebrityFriends int) *TimelineFanout {
+	return &TimelineFanout{
+		db:               db,
+		queue:            make(chan timelineJob, queueSize),
+		celebrityFriends: celebrityFriends,
+		now:              time.Now,
+	}
+}
+
+// PostPublished writes the post to the timelines of the friends of the author
+func (fanout *TimelineFanout) PostPublished(postID string) {
+	fanout.enqueue(timelineJob{kind: timelinePostPublished, postID: postID})
+}
+
+// FriendshipAdded copies the posts of the new friends to each other's timeline
+func (fanout *TimelineFanout) FriendshipAdded(user1ID string, user2ID string) {
+	fanout.enqueue(timelineJob{kind: timelineFriendshipAdded, user1ID: user1ID, user2ID: user2ID})
+}
+
+// FriendshipRemoved removes the posts of the former friends from each other's timeline
+func (fanout *TimelineFanout) FriendshipRemoved(user1ID string, user2ID string) {
+	fanout.enqueue(timelineJob{kind: timelineFriendshipRemoved, user1ID: user1ID, user2ID: user2ID})
+}
+
+// enqueue adds the job to the queue. Unlike the link previews the jobs are not skipped when the queue is full,
+// a skipped job would leave the timelines wrong until they are rebuilt, so the job is done right away instead.
+func (fanout *TimelineFanout) enqueue(job timelineJob) {
+	if fanout == nil {
+		return
+	}
+	select {
+	case fanout.queue <- job:
+	default:
+		log.Println("timelines: queue is full, running the job right away")
+		fanout.process(job)
+	}
+}
+
+// Run does the queued jobs one by one, so that the friendship changes are applied in order. It never returns.
+func (fanout *TimelineFanout) Run() {
+	for job := range fanout.queue {
+		fanout.process(job)
+	}
+}
+
+func (fanout *TimelineFanout) process(job timelineJob) {
+	var err error
+	switch job.kind {
+	case timelinePostPublished:
+		err = fanout.fanOutPost(job.postID)
+	case timelineFriendshipAdded:
+		err = fanout.addFriendship(job.user1ID, job.user2ID)
+	case timelineFriendshipRemoved:
+		err = fanout.removeFriendship(job.user1ID, job.user2ID)
+	}
+	if err != nil {
+		log.Println("timelines: failed to process the", job.kind, "job:", err)
+	}
+}
+
+func (fanout *TimelineFanout) fanOutPost(postID string) error {
+
+	//the post is read again for the creation time set by the database, the post deleted in the meantime is skipped
+	post, err := models.GetPostByID(fanout.db, postID)
+	if err != nil {
+		return err
+	}
+	if post.Status != models.PostStatusPublished {
+		return nil
+	}
+
+	//the posts of the celebrities are read on the fan-out
+	celebrity, err := fanout.refreshCelebrity(post.UserID)
+	if err != nil || celebrity {
+		return err
+	}
+	return models.AddToTimelines(fanout.db, post, models.GetFriendsIDs(fanout.db, post.UserID))
+}
+
+func (fanout *TimelineFanout) addFriendship(user1ID string, user2ID string) error {
+
+	//copy the posts of each friend to the timeline of the other one, unless the friend is a celebrity
+	for _, users := range [][2]string{{user1ID, user2ID}, {user2ID, user1ID}} {
+		userID, authorID := users[0], users[1]
+		celebrity, err := fanout.refreshCelebrity(authorID)
+		if err != nil {
+			return err
+		}
+		if celebrity {
+			continue
+		}
+		if err := models.BackfillTimeline(fanout.db, userID, authorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fanout *TimelineFanout) removeFriendship(user1ID string, user2ID string) error {
+
+	//remove the posts of the former friends, then check whether they are still celebrities
+	if err := models.RemoveFromTimeline(fanout.db, user1ID, user2ID); err != nil {
+		return err
+	}
+	if err := models.RemoveFromTimeline(fanout.db, user2ID, user1ID); err != nil {
+		return err
+	}
+	if _, err := fanout.refreshCelebrity(user1ID); err != nil {
+		return err
+	}
+	_, err := fanout.refreshCelebrity(user2ID)
+	return err
+}
+
+// refreshCelebrity checks the number of the friends of the author. The new celebrity is removed from the timelines,
+// and the posts of the former celebrity are written to the timelines of all friends.
+func (fanout *TimelineFanout) refreshCelebrity(authorID string) (bool, error) {
+	friends, err := models.CountFriends(fanout.db, authorID)
+	if err != nil {
+		return false, err
+	}
+	wasCelebrity, err := models.IsCelebrity(fanout.db, authorID)
+	if err != nil {
+		return false, err
+	}
+	celebrity := friends > fanout.celebrityFriends
+
+	switch {
+	case celebrity && !wasCelebrity:
+		if err := models.SetCelebrity(fanout.db, authorID, true, fanout.now()); err != nil {
+			return celebrity, err
+		}
+		return celebrity, models.RemoveAuthorFromTimelines(fanout.db, authorID)
+	case !celebrity && wasCelebrity:
+		if err := models.SetCelebrity(fanout.db, authorID, false, fanout.now()); err != nil {
+			return celebrity, err
+		}
+		for _, friendID := range models.GetFriendsIDs(fanout.db, authorID) {
+			if err := models.BackfillTimeline(fanout.db, friendID, authorID); err != nil {
+				return celebrity, err
+			}
+		}
+	}
+	return celebrity, nil
+}
+
+// Rebuild writes the timelines of the users again from the friendships and the posts, it repairs the timelines
+// after the failed jobs. All users are rebuilt when no user is given.
+func (fanout *TimelineFanout) Rebuild(userIDs ...string) error {
+	if len(userIDs) == 0 {
+		var err error
+		if userIDs, err = models.GetAllUserIDs(fanout.db); err != nil {
+			return err
+		}
+	}
+
+	//refresh the celebrities first, so that their posts are not copied to the timelines
+	for _, userID := range userIDs {
+		if _, err := fanout.refreshCelebrity(userID); err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range userIDs {
+		if err := models.ClearTimeline(fanout.db, userID); err != nil {
+			return err
+		}
+		for _, friendID := range models.GetFriendsIDs(fanout.db, userID) {
+			celebrity, err := models.IsCelebrity(fanout.db, friendID)
+			if err != nil {
+				return err
+			}
+			if celebrity {
+				continue
+			}
+			if err := models.BackfillTimeline(fanout.db, userID, friendID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dika-bosnjak/social-media-app/pkg/models"
+)
+
+var postRowColumns = []string{"id", "photo", "text", "user_id", "repost_of_id", "hidden", "status", "publish_at", "edited_at", "pinned_at", "created_at", "updated_at"}
+
+func expectPublishedPost(mock sqlmock.Sqlmock, createdAt time.Time) {
+	mock.ExpectQuery("FROM posts").WithArgs("post-id").
+		WillReturnRows(sqlmock.NewRows(postRowColumns).
+			AddRow("post-id", "", "New post", "author-id", "", false, models.PostStatusPublished, nil, nil, nil, createdAt, createdAt))
+}
+
+func TestFanOutPostWritesFriendsTimelines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	fanout := NewTimelineFanout(db, 10, 2)
+	expectPublishedPost(mock, now)
+	mock.ExpectQuery("SELECT COUNT").WithArgs("author-id", "author-id").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("FROM celebrities").WithArgs("author-id").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM friendships").WithArgs("author-id", "author-id").
+		WillReturnRows(sqlmock.NewRows([]string{"friendship_id", "friend_id", "first_name", "last_name", "user_photo_url"}).
+			AddRow("f1", "friend-1", "", "", "").
+			AddRow("f2", "friend-2", "", "", ""))
+
+	//the post is written to the timeline of every friend with the creation time from the database
+	mock.ExpectExec("INSERT IGNORE INTO timelines").
+		WithArgs("friend-1", "post-id", "author-id", now, "friend-2", "post-id", "author-id", now).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+
+	if err := fanout.fanOutPost("post-id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFanOutPostSkipsCelebrities(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2022, 11, 10, 12, 0, 0, 0, time.UTC)
+	fanout := NewTimelineFanout(db, 10, 2)
+	fanout.now = func() time.Time { return now }
+	expectPublishedPost(mock, now)
+
+	//the author got more friends than the limit, so the author becomes a celebrity and is removed from the timelines
+	mock.ExpectQuery("SELECT COUNT").WithArgs("author-id", "author-id").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("FROM celebrities").WithArgs("author-id").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT IGNORE INTO celebrities").WithArgs("author-id", now).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM timelines").WithArgs("author-id").WillReturnResult(sqlmock.NewResult(0, 5))
+
+	if err := fanout.fanOutPost("post-id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTimelineFanoutIsNilSafe(t *testing.T) {
+	var fanout *TimelineFanout
+	fanout.PostPublished("post-id")
+	fanout.FriendshipAdded("user-1", "user-2")
+	fanout.FriendshipRemoved("user-1", "user-2")
+}